@@ -0,0 +1,135 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// BalanceAllocator tracks the Dmarket USD balance and the funds reserved by purchases in flight
+type BalanceAllocator struct {
+	mutex        sync.Mutex
+	available    float64
+	reservations map[string]float64
+	// Incremented on every commit or credit, so a balance fetched before one can be discarded
+	commits int
+}
+
+var balances = NewBalanceAllocator()
+
+func NewBalanceAllocator() *BalanceAllocator {
+	return &BalanceAllocator{reservations: make(map[string]float64)}
+}
+
+// free must be called with the mutex held
+func (b *BalanceAllocator) free() float64 {
+	free := b.available
+	for _, amount := range b.reservations {
+		free -= amount
+	}
+
+	return free
+}
+
+// Free returns the balance not already reserved by another purchase
+func (b *BalanceAllocator) Free() float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.free()
+}
+
+func (b *BalanceAllocator) Available() float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.available
+}
+
+func (b *BalanceAllocator) Reserved() float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.available - b.free()
+}
+
+// Reserve sets aside amount for the purchase with the given ID, returning false if there isn't enough free balance
+func (b *BalanceAllocator) Reserve(id string, amount float64) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.reservations[id]; ok || b.free() < amount {
+		return false
+	}
+
+	b.reservations[id] = amount
+	return true
+}
+
+// Set replaces a standing reservation, such as the cost of an open target, whatever the free balance
+func (b *BalanceAllocator) Set(id string, amount float64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.reservations[id] = amount
+}
+
+// Commit deducts a reservation from the balance once the purchase has gone through
+func (b *BalanceAllocator) Commit(id string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if amount, ok := b.reservations[id]; ok {
+		b.available -= amount
+		delete(b.reservations, id)
+		b.commits++
+	}
+}
+
+// Credit adds sale proceeds to the balance
+func (b *BalanceAllocator) Credit(amount float64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.available += amount
+	b.commits++
+}
+
+// Release returns a reservation to the free balance after a failed purchase
+func (b *BalanceAllocator) Release(id string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.reservations, id)
+}
+
+// BeginReconcile must be called before fetching the balance, the returned token is passed to Reconcile
+func (b *BalanceAllocator) BeginReconcile() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.commits
+}
+
+// Reconcile replaces the tracked balance with the one reported by Dmarket, unless a purchase was committed since the fetch began
+func (b *BalanceAllocator) Reconcile(fetched float64, token int) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.commits != token {
+		return false
+	}
+
+	b.available = fetched
+	return true
+}
+
+func RunBalanceReconciler(delaySeconds int) {
+	if delaySeconds <= 0 {
+		return
+	}
+
+	ticker := time.Tick(time.Duration(delaySeconds) * time.Second)
+	for range ticker {
+		UpdateAvailableBalance()
+	}
+}
@@ -16,14 +16,15 @@ type SuggestionResponse struct {
 	Msg string `json:"msg"`
 }
 
-func GetBuffPrice(itemName string, phase string) float64 {
+// GetBuffPrice returns the highest Buff buy order, which the price feed provides converted to USD
+func GetBuffPrice(itemName string, phase string) Money {
 	prices := marketPrices[itemName]
 
 	if phase != "" && phase != "default" {
-		return prices.Buff163.HighestOrder.Doppler[phase]
+		return NewMoney(prices.Buff163.HighestOrder.Doppler[phase], USD)
 	}
 
-	return prices.Buff163.HighestOrder.Price
+	return NewMoney(prices.Buff163.HighestOrder.Price, USD)
 }
 
 func GetBuffUrl(name string) string {
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"csgoTrader/skinport"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const COOKIE_DROP_POLL = 5 * time.Second
+
+// Files modified more recently than this may still be being written
+const COOKIE_DROP_SETTLE = 2 * time.Second
+
+// Signalled when a dropped cookie export logs in, waking a login waiting on it
+var cookieImports = make(chan struct{})
+
+// ImportSkinportCookies logs in with the skinport.com cookies of a browser cookie export
+func ImportSkinportCookies(path string) error {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	cookies, err := skinport.ParseCookieExport(file)
+	if err != nil {
+		return err
+	}
+
+	// Checked on a scratch session first, so cookies which aren't logged in don't replace a working session
+	ctx := context.Background()
+	scratch := skinport.NewSession()
+	scratch.Visit(ctx)
+	scratch.SetCookies(cookies)
+
+	user, err := scratch.User(ctx)
+	if err != nil {
+		return errors.New("Imported cookies aren't logged in: " + err.Error())
+	}
+
+	var loggedIn []*http.Cookie
+	for _, cookie := range scratch.Cookies() {
+		loggedIn = append(loggedIn, &http.Cookie{Name: cookie.Name, Value: cookie.Value, Path: "/", Domain: "." + skinport.COOKIE_DOMAIN})
+	}
+	skinportSession.SetCookies(loggedIn)
+
+	InfoLogger.Println("Imported " + strconv.Itoa(len(cookies)) + " Skinport cookies for " + user.Username)
+	SaveSkinportSession()
+
+	return nil
+}
+
+// RunCookieDropFolder imports cookie exports dropped into the folder, deleting them once read as they hold the session
+func RunCookieDropFolder(folder string) {
+	if folder == "" {
+		return
+	}
+
+	if err := os.MkdirAll(folder, 0700); err != nil {
+		ErrorLogger.Println("Failed to create the cookie drop folder: " + err.Error())
+		return
+	}
+
+	ticker := time.Tick(COOKIE_DROP_POLL)
+	for range ticker {
+		files, err := ioutil.ReadDir(folder)
+		if err != nil {
+			ErrorLogger.Println("Failed to read the cookie drop folder: " + err.Error())
+			continue
+		}
+
+		for _, file := range files {
+			if file.IsDir() || time.Since(file.ModTime()) < COOKIE_DROP_SETTLE {
+				continue
+			}
+
+			path := filepath.Join(folder, file.Name())
+			err = ImportSkinportCookies(path)
+			os.Remove(path)
+
+			if err != nil {
+				ReportError(errors.New("Failed to import " + file.Name() + ": " + err.Error()))
+				continue
+			}
+
+			select {
+			case cookieImports <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
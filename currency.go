@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sync"
+	"time"
+)
+
+type Currency string
+
+const (
+	USD Currency = "USD"
+	GBP Currency = "GBP"
+	EUR Currency = "EUR"
+	CNY Currency = "CNY"
+)
+
+var currencySymbols = map[Currency]string{
+	USD: "$",
+	GBP: "£",
+	EUR: "€",
+	CNY: "¥",
+}
+
+// Money is an amount in a specific currency, prices should never be compared without one
+type Money struct {
+	Amount   float64  `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+func NewMoney(amount float64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+func MoneyFromCents(cents int, currency Currency) Money {
+	return Money{Amount: float64(cents) / 100, Currency: currency}
+}
+
+func (m Money) Cents() int {
+	return int(math.Round(m.Amount * 100))
+}
+
+func (m Money) String() string {
+	if symbol, ok := currencySymbols[m.Currency]; ok {
+		return fmt.Sprintf("%s%.2f", symbol, m.Amount)
+	}
+
+	return fmt.Sprintf("%.2f %s", m.Amount, m.Currency)
+}
+
+type CurrencyService struct {
+	mutex sync.RWMutex
+	// Units of each currency per 1 USD
+	rates   map[Currency]float64
+	spread  float64
+	updated time.Time
+}
+
+var currencies = NewCurrencyService()
+
+func NewCurrencyService() *CurrencyService {
+	return &CurrencyService{rates: map[Currency]float64{USD: 1}}
+}
+
+// SetRates stores rates given as units of each currency per 1 of an arbitrary base currency
+func (c *CurrencyService) SetRates(rates map[string]float64) error {
+	usdRate := rates[string(USD)]
+	if usdRate == 0 {
+		return errors.New("Rates do not include USD")
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for name, rate := range rates {
+		if rate > 0 {
+			c.rates[Currency(name)] = rate / usdRate
+		}
+	}
+	c.updated = time.Now()
+
+	return nil
+}
+
+func (c *CurrencyService) SetSpread(percentage float64) {
+	c.mutex.Lock()
+	c.spread = percentage
+	c.mutex.Unlock()
+}
+
+func (c *CurrencyService) LoadRatesFile(path string) error {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var rates map[string]float64
+	if err = json.Unmarshal(file, &rates); err != nil {
+		return err
+	}
+
+	return c.SetRates(rates)
+}
+
+func (c *CurrencyService) UpdateFromSkinport() error {
+	apiData, err := skinportSession.Data(context.Background())
+	if err != nil {
+		return err
+	}
+
+	return c.SetRates(apiData.Rates)
+}
+
+// ConvertAtMid converts at the raw rate, for amounts which have to match what a market quotes
+func (c *CurrencyService) ConvertAtMid(m Money, to Currency) (Money, error) {
+	if m.Currency == to {
+		return m, nil
+	}
+
+	c.mutex.RLock()
+	fromRate := c.rates[m.Currency]
+	toRate := c.rates[to]
+	c.mutex.RUnlock()
+
+	if fromRate == 0 || toRate == 0 {
+		return Money{}, errors.New("No exchange rate available from " + string(m.Currency) + " to " + string(to))
+	}
+
+	return NewMoney(m.Amount/fromRate*toRate, to), nil
+}
+
+// Convert converts with the configured spread deducted, for valuing amounts we would have to exchange ourselves
+func (c *CurrencyService) Convert(m Money, to Currency) (Money, error) {
+	converted, err := c.ConvertAtMid(m, to)
+	if err != nil || m.Currency == to {
+		return converted, err
+	}
+
+	c.mutex.RLock()
+	converted.Amount *= 1 - c.spread/100
+	c.mutex.RUnlock()
+
+	return converted, nil
+}
+
+func RunCurrencyUpdates(delayMinutes int) {
+	if delayMinutes <= 0 {
+		return
+	}
+
+	ticker := time.Tick(time.Duration(delayMinutes) * time.Minute)
+	for range ticker {
+		if err := currencies.UpdateFromSkinport(); err != nil {
+			ErrorLogger.Println("Failed to update exchange rates: " + err.Error())
+		}
+	}
+}
+
+// ProfitPercentage compares cost and revenue in the currency of the cost
+func ProfitPercentage(cost Money, revenue Money) (float64, error) {
+	converted, err := currencies.Convert(revenue, cost.Currency)
+	if err != nil {
+		return 0, err
+	}
+
+	return PercentageDifference(cost.Amount, converted.Amount), nil
+}
+
+// InPriceRange checks a price against the configured minimum and maximum, which are in USD
+func InPriceRange(price Money) bool {
+	usd, err := currencies.ConvertAtMid(price, USD)
+	if err != nil {
+		return false
+	}
+
+	return usd.Amount >= config.MinimumPrice && usd.Amount <= config.MaximumPrice
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"csgoTrader/dmarket"
 	"fmt"
 	"github.com/disgoorg/disgo"
 	"github.com/disgoorg/disgo/bot"
@@ -25,9 +26,14 @@ func CreateWebhookClient(webhookUrl string) {
 	WebhookClient = webhook.New(snowflake.ID(id), token)
 }
 
-func SendDmarketPurchase(product *DmarketProduct, marketType string, orderId string) {
+func SendDmarketPurchase(product *dmarket.Product, marketType string, orderId string, previousPrice Money) {
+	title := "Successful Purchase: "
+	if previousPrice.Amount > 0 {
+		title = "Successful Price Drop Purchase: "
+	}
+
 	var embed = discord.NewEmbedBuilder()
-	embed.SetTitle("Successful Purchase: " + orderId).SetURL("https://dmarket.com/ingame-items/item-list/csgo-skins")
+	embed.SetTitle(title + orderId).SetURL("https://dmarket.com/ingame-items/item-list/csgo-skins")
 	embed.SetTimestamp(time.Now()).SetThumbnail(product.Image)
 
 	var description string
@@ -39,50 +45,161 @@ func SendDmarketPurchase(product *DmarketProduct, marketType string, orderId str
 	embed.SetDescription(description)
 
 	buffPrice := GetBuffPrice(product.Title, product.Extra.PhaseTitle)
-	numPrice, _ := strconv.ParseFloat(product.Price.USD, 32)
-	numPrice = numPrice / 100
+	price := GetDmarketPrice(product)
+	profit, _ := ProfitPercentage(price, buffPrice)
 
 	inline := true
 	embed.SetColor(5763719)
 	embed.SetFields(discord.EmbedField{
 		Name:   "Price",
-		Value:  fmt.Sprintf("$%.2f (%.2f%%)", numPrice, PercentageDifference(numPrice, buffPrice)),
+		Value:  fmt.Sprintf("%s (%.2f%%)", price, profit),
 		Inline: &inline,
 	}, discord.EmbedField{
 		Name:   "Buff Price",
-		Value:  fmt.Sprintf("[$%.2f](%s)", buffPrice, GetBuffUrl(product.Title)),
+		Value:  fmt.Sprintf("[%s](%s)", buffPrice, GetBuffUrl(product.Title)),
 		Inline: &inline,
 	})
 
+	if previousPrice.Amount > 0 {
+		embed.AddField("Price Drop", fmt.Sprintf("%s -> %s", previousPrice, price), inline)
+	}
+
 	WebhookClient.CreateEmbeds([]discord.Embed{embed.Build()})
 }
 
-func SendSkinportProduct(name string, image string, inspectLink string, price float64, purchaseUrl string, buffPrice float64, marketName string) {
+func SendSkinportProduct(name string, image string, inspectLink string, price Money, purchaseUrl string, buffPrice Money, marketName string) {
 	var embed = discord.NewEmbedBuilder()
 	embed.SetTitle(marketName + ": " + name).SetURL(purchaseUrl)
 	embed.SetTimestamp(time.Now()).SetThumbnail(image)
 
+	profit, _ := ProfitPercentage(price, buffPrice)
+
 	inline := true
 	embed.SetFields(discord.EmbedField{
 		Name:   "Price",
-		Value:  fmt.Sprintf("$%.2f (%.2f%%)", price, PercentageDifference(price, buffPrice)),
+		Value:  fmt.Sprintf("%s (%.2f%%)", price, profit),
 		Inline: &inline,
 	}, discord.EmbedField{
 		Name:   "Buff Price",
-		Value:  fmt.Sprintf("[$%.2f](%s)", buffPrice, GetBuffUrl(name)),
+		Value:  fmt.Sprintf("[%s](%s)", buffPrice, GetBuffUrl(name)),
 		Inline: &inline,
 	})
 
 	WebhookClient.CreateEmbeds([]discord.Embed{embed.Build()})
 }
 
-func ReportATC() {
-	webhookArray := strings.Split(config.Webhook, "/")
-	id, _ := strconv.ParseUint(webhookArray[len(webhookArray)-2], 10, 64)
-	token := webhookArray[len(webhookArray)-1]
+func SendTargetFill(record TradeRecord) {
+	var embed = discord.NewEmbedBuilder()
+	embed.SetTitle("Target Filled: " + record.Title).SetURL("https://dmarket.com/ingame-items/item-list/csgo-skins")
+	embed.SetTimestamp(record.CreatedAt).SetColor(5763719)
+
+	buffPrice := GetBuffPrice(record.Title, "")
+	profit, _ := ProfitPercentage(record.Price, buffPrice)
+
+	inline := true
+	embed.AddField("Price", fmt.Sprintf("%s (%.2f%%)", record.Price, profit), inline)
+	embed.AddField("Buff Price", fmt.Sprintf("[%s](%s)", buffPrice, GetBuffUrl(record.Title)), inline)
+
+	WebhookClient.CreateEmbeds([]discord.Embed{embed.Build()})
+}
+
+func SendInstantFlip(product *dmarket.Product, record TradeRecord) {
+	var embed = discord.NewEmbedBuilder()
+	embed.SetTitle("Instant Flip: " + record.Title).SetURL("https://dmarket.com/ingame-items/item-list/csgo-skins")
+	embed.SetTimestamp(time.Now()).SetThumbnail(product.Image).SetColor(5763719)
+
+	profit, _ := ProfitPercentage(record.Price, record.SalePrice)
+
+	inline := true
+	embed.AddField("Bought", record.Price.String(), inline)
+	embed.AddField("Sold To Target", fmt.Sprintf("%s (%.2f%%)", record.SalePrice, profit), inline)
+
+	WebhookClient.CreateEmbeds([]discord.Embed{embed.Build()})
+}
+
+func SendListingSold(record TradeRecord) {
+	var embed = discord.NewEmbedBuilder()
+	embed.SetTitle("Listing Sold: " + record.Title).SetURL("https://dmarket.com/ingame-items/item-list/csgo-skins")
+	embed.SetTimestamp(time.Now()).SetColor(5763719)
+
+	profit, _ := ProfitPercentage(record.Price, record.SalePrice)
+
+	inline := true
+	embed.AddField("Bought", record.Price.String(), inline)
+	embed.AddField("Sold", fmt.Sprintf("%s (%.2f%%)", record.SalePrice, profit), inline)
+
+	WebhookClient.CreateEmbeds([]discord.Embed{embed.Build()})
+}
+
+func SendP2PUpdate(record TradeRecord, message string) {
+	var embed = discord.NewEmbedBuilder()
+	embed.SetTitle("P2P Trade: " + record.Title).SetURL("https://dmarket.com/ingame-items/item-list/csgo-skins")
+	embed.SetTimestamp(time.Now()).SetDescription(message)
 
-	atcClient := webhook.New(snowflake.ID(id), token)
-	atcClient.CreateMessage(discord.WebhookMessageCreate{Content: "Item ATCd: https://skinport.com/cart"})
+	inline := true
+	embed.AddField("Price", record.Price.String(), inline)
+	embed.AddField("Offer", record.ID, inline)
+
+	WebhookClient.CreateEmbeds([]discord.Embed{embed.Build()})
+}
+
+func SendReconciledPurchase(record TradeRecord) {
+	var embed = discord.NewEmbedBuilder()
+	embed.SetTitle("Purchase Settled: " + record.Title).SetURL("https://dmarket.com/ingame-items/item-list/csgo-skins")
+	embed.SetTimestamp(time.Now())
+
+	switch record.Status {
+	case TRADE_COMPLETED:
+		embed.SetDescription("Found in the purchase history, the item was bought.").SetColor(5763719)
+	case TRADE_PENDING:
+		embed.SetDescription("Found in the purchase history, P2P trade started. SEND TRADE NOW.").SetColor(5763719)
+	default:
+		embed.SetDescription("Not bought, the reserved balance has been released.")
+	}
+
+	inline := true
+	embed.AddField("Price", record.Price.String(), inline)
+	embed.AddField("Offer", record.ID, inline)
+
+	WebhookClient.CreateEmbeds([]discord.Embed{embed.Build()})
+}
+
+func SendCartReady(entries []CartEntry, total Money) {
+	var embed = discord.NewEmbedBuilder()
+	embed.SetTitle("Skinport Cart Ready").SetURL("https://skinport.com/cart")
+	embed.SetTimestamp(time.Now()).SetColor(5763719)
+
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf("%s - %s", entry.Title, entry.Price))
+	}
+	embed.SetDescription(strings.Join(lines, "\n"))
+
+	inline := true
+	embed.AddField("Items", strconv.Itoa(len(entries)), inline)
+	embed.AddField("Total", total.String(), inline)
+
+	WebhookClient.CreateEmbeds([]discord.Embed{embed.Build()})
+}
+
+func SendSkinportFeedStatus(message string) {
+	WebhookClient.CreateMessage(discord.WebhookMessageCreate{Content: message})
+}
+
+func SendLoginPrompt(message string) {
+	WebhookClient.CreateMessage(discord.WebhookMessageCreate{Content: message})
+}
+
+func SendStatus(status []StatusEntry) {
+	var embed = discord.NewEmbedBuilder()
+	embed.SetTitle("Status").SetTimestamp(time.Now())
+
+	inline := true
+	for _, entry := range status {
+		embed.AddField(entry.Name, entry.Value, inline)
+	}
+
+	WebhookClient.CreateEmbeds([]discord.Embed{embed.Build()})
 }
 
 func ReportError(err error) {
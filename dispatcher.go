@@ -0,0 +1,124 @@
+package main
+
+import (
+	"container/heap"
+	"csgoTrader/dmarket"
+	"sync"
+)
+
+const DEFAULT_PURCHASE_WORKERS = 4
+
+// PurchaseJob holds its own copy of the product so workers never share a loop variable
+type PurchaseJob struct {
+	Product    dmarket.Product
+	MarketType string
+	// Expected profit in USD, higher profit jobs are purchased first
+	ExpectedProfit float64
+	// Price the offer was previously seen at, if it has since dropped
+	PreviousPrice Money
+	// Whether the product is sold straight into the instant target after buying
+	Flip bool
+}
+
+func NewPurchaseJob(product dmarket.Product, marketType string) PurchaseJob {
+	price := GetDmarketPrice(&product)
+	revenue := ExpectedRevenue(product.Title, product.Extra.PhaseTitle, GetDmarketLock(&product))
+
+	return PurchaseJob{
+		Product:        product,
+		MarketType:     marketType,
+		ExpectedProfit: toUsd(revenue) - toUsd(price),
+	}
+}
+
+type purchaseQueue []PurchaseJob
+
+func (q purchaseQueue) Len() int           { return len(q) }
+func (q purchaseQueue) Less(i, j int) bool { return q[i].ExpectedProfit > q[j].ExpectedProfit }
+func (q purchaseQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *purchaseQueue) Push(job interface{}) {
+	*q = append(*q, job.(PurchaseJob))
+}
+
+func (q *purchaseQueue) Pop() interface{} {
+	old := *q
+	job := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return job
+}
+
+// PurchaseDispatcher runs Dmarket purchases on a bounded pool of workers
+type PurchaseDispatcher struct {
+	mutex    sync.Mutex
+	ready    *sync.Cond
+	queue    purchaseQueue
+	inFlight int
+}
+
+var dispatcher *PurchaseDispatcher
+
+func NewPurchaseDispatcher(workers int) *PurchaseDispatcher {
+	if workers <= 0 {
+		workers = DEFAULT_PURCHASE_WORKERS
+	}
+
+	d := &PurchaseDispatcher{}
+	d.ready = sync.NewCond(&d.mutex)
+
+	for i := 0; i < workers; i++ {
+		go d.work()
+	}
+
+	return d
+}
+
+// Submit queues all jobs at once, so deals found in the same poll are prioritised against each other
+func (d *PurchaseDispatcher) Submit(jobs ...PurchaseJob) {
+	if len(jobs) == 0 {
+		return
+	}
+
+	d.mutex.Lock()
+	for _, job := range jobs {
+		heap.Push(&d.queue, job)
+	}
+	d.mutex.Unlock()
+
+	d.ready.Broadcast()
+}
+
+func (d *PurchaseDispatcher) QueueDepth() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.queue.Len()
+}
+
+func (d *PurchaseDispatcher) InFlight() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.inFlight
+}
+
+func (d *PurchaseDispatcher) work() {
+	for {
+		d.mutex.Lock()
+		for d.queue.Len() == 0 {
+			d.ready.Wait()
+		}
+
+		job := heap.Pop(&d.queue).(PurchaseJob)
+		d.inFlight++
+		d.mutex.Unlock()
+
+		if PurchaseProduct(&job) && job.Flip {
+			SellToInstantTarget(&job)
+		}
+
+		d.mutex.Lock()
+		d.inFlight--
+		d.mutex.Unlock()
+	}
+}
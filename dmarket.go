@@ -1,319 +1,278 @@
 package main
 
 import (
-	"encoding/json"
+	"csgoTrader/dmarket"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 )
 
-var apiUrl = "https://api.dmarket.com/exchange/v1/market/items?side=market&orderBy=updated&orderDir=desc&title=&priceFrom=" + fmt.Sprintf("%f", config.MinimumPrice) + "&priceTo=" + fmt.Sprintf("%f", config.MinimumPrice) + "&treeFilters=&gameId=a8db&cursor=&limit=100&currency=USD&platform=browser&isLoggedIn=false&types="
+const DEFAULT_MAX_CLOCK_SKEW = 5
 
-var balance float64
+var dmarketClient = dmarket.NewClient("", "", dmarket.DEFAULT_BASE_URL, http.DefaultClient)
 
-func RunDmarket(delayMs int, marketType string) {
-	ticker := time.Tick(time.Duration(delayMs) * time.Millisecond)
+// NewDmarketClient creates the client shared by every Dmarket caller, paced by the scheduler
+func NewDmarketClient(publicKey string, privateKey string) *dmarket.Client {
+	client := dmarket.NewClient(publicKey, privateKey, dmarket.DEFAULT_BASE_URL, http.DefaultClient)
+	client.Limiter = scheduler
 
+	client.Clock.MaxSkew = time.Duration(config.MaxClockSkew) * time.Second
+	if config.MaxClockSkew <= 0 {
+		client.Clock.MaxSkew = DEFAULT_MAX_CLOCK_SKEW * time.Second
+	}
+	client.Clock.OnSkew = func(offset time.Duration) {
+		WarningLogger.Println("Local clock is off from Dmarket by", offset.Round(time.Millisecond))
+		ReportError(errors.New("Local clock is off from Dmarket by " + offset.Round(time.Millisecond).String() + ", requests are signed with the corrected time but the system clock should be synced"))
+	}
+
+	return client
+}
+
+func RunDmarket(delayMs int, query *dmarket.ItemsQuery) {
 	firstTime := true
-	var newProducts []DmarketProduct
-	var products []DmarketProduct
+	marketTypes := strings.Join(query.Types, ",")
+
+	for {
+		// Slows down with the scheduler when Dmarket is erroring or rate limiting us
+		time.Sleep(scheduler.PollDelay(delayMs))
+		InfoLogger.Println("Fetching new dmarket items (" + marketTypes + ")")
+
+		productsObj, err := FetchDmarketProducts(query, PRIORITY_POLL)
+		if err != nil {
+			ErrorLogger.Println(err)
+			continue
+		}
 
+		// Listings on the first poll are only marked as seen unless configured otherwise
+		EvaluateDmarketProducts(productsObj.Objects, !firstTime || config.EvaluateInitialPage)
+		firstTime = false
+	}
+}
+
+// RunDmarketSweep periodically walks every page of the feed, to find deals posted while we were down or buried past the first page
+func RunDmarketSweep(delayMinutes int, pageDelayMs int, query *dmarket.ItemsQuery) {
+	if delayMinutes <= 0 {
+		return
+	}
+
+	// Sweep straight away to catch up on what was listed while we were down
+	SweepDmarket(pageDelayMs, query)
+
+	ticker := time.Tick(time.Duration(delayMinutes) * time.Minute)
 	for range ticker {
-		InfoLogger.Println("Fetching new dmarket items (" + marketType + ")")
+		SweepDmarket(pageDelayMs, query)
+	}
+}
 
-		response, err := http.DefaultClient.Get(apiUrl + marketType)
+// SweepDmarket evaluates every page of the feed once
+func SweepDmarket(pageDelayMs int, query *dmarket.ItemsQuery) {
+	marketTypes := strings.Join(query.Types, ",")
+	InfoLogger.Println("Sweeping dmarket items (" + marketTypes + ")")
 
+	cursor := ""
+	pages := 0
+
+	for {
+		productsObj, err := FetchDmarketProducts(query.WithCursor(cursor), PRIORITY_SWEEP)
 		if err != nil {
-			fmt.Println(err)
-			continue
+			ErrorLogger.Println(err)
+			break
 		}
 
-		if response.StatusCode != 200 {
-			ErrorLogger.Println("Erroneous response received: " + strconv.Itoa(response.StatusCode))
-			continue
+		EvaluateDmarketProducts(productsObj.Objects, true)
+		pages++
+
+		if productsObj.Cursor == "" || len(productsObj.Objects) == 0 {
+			break
 		}
 
-		body, err := ioutil.ReadAll(response.Body)
-		response.Body.Close()
+		cursor = productsObj.Cursor
+		time.Sleep(scheduler.PollDelay(pageDelayMs))
+	}
+
+	InfoLogger.Println("Finished sweeping dmarket items ("+marketTypes+"), pages:", pages)
+}
+
+func FetchDmarketProducts(query *dmarket.ItemsQuery, priority int) (dmarket.ProductsResponse, error) {
+	return dmarketClient.MarketItems(PriorityContext(priority), query)
+}
+
+// EvaluateDmarketProducts marks products as seen and dispatches purchases for the new profitable ones, routed by their offer type
+func EvaluateDmarketProducts(products []dmarket.Product, evaluate bool) {
+	var jobs []PurchaseJob
 
-		var productsObj DmarketProductsResponse
-		var toSend []DmarketProduct
-		json.Unmarshal(body, &productsObj)
+	for _, product := range products {
+		isNew, previousPrice := seen.Check(product.Extra.OfferID, product.Price.USD)
+		if !isNew {
+			continue
+		}
+		// StatTrak items are never bought, whether to hold or to flip
+		if !evaluate || strings.Contains(product.Title, "StatTrak") {
+			seen.Mark(product.Extra.OfferID, product.Price.USD)
+			continue
+		}
 
-		newProducts = productsObj.Objects
+		marketType := GetDmarketOfferType(&product)
+		price := GetDmarketPrice(&product)
+		job := NewPurchaseJob(product, marketType)
 
-		for _, product := range productsObj.Objects {
-			send := true
+		// Sellers cutting the price of an existing offer are re-evaluated, as orderBy=updated surfaces them again
+		if previousCents, err := strconv.Atoi(previousPrice); err == nil && previousCents > price.Cents() {
+			job.PreviousPrice = MoneyFromCents(previousCents, USD)
+			InfoLogger.Println("Price drop", product.Title, job.PreviousPrice, "->", price)
+		}
 
-			for _, oldProduct := range products {
-				if oldProduct.ProductID == product.ProductID {
-					send = false
-				}
-			}
+		InfoLogger.Println("Found product", product.Title, marketType)
 
-			if send {
-				toSend = append(toSend, product)
-			}
+		// Only skipped for a while, so the offer is evaluated again once the balance may have recovered
+		if price.Amount > balances.Free() {
+			seen.MarkFor(product.Extra.OfferID, product.Price.USD, SEEN_UNAFFORDABLE_TTL)
+			continue
 		}
+		seen.Mark(product.Extra.OfferID, product.Price.USD)
 
-		for _, product := range toSend {
-			InfoLogger.Println("Found product", product.Title, marketType)
-			buffPrice := GetBuffPrice(product.Title, product.Extra.PhaseTitle)
-			numPrice, _ := strconv.ParseFloat(product.Price.USD, 32)
-			numPrice = numPrice / 100
-			if !firstTime && numPrice <= balance && (PercentageDifference(numPrice, buffPrice) >= config.MinimumProfitPercentage) && !strings.Contains(product.Title, "StatTrak") {
-				go PurchaseProduct(&product, marketType)
-			}
+		allowed, sellerMargin := CheckSeller(&product)
+		if !allowed {
+			continue
 		}
 
-		products = newProducts
-		firstTime = false
+		if config.InstantFlip && IsInstantFlip(&product) {
+			job.Flip = true
+			job.ExpectedProfit = toUsd(GetInstantSellNet(&product)) - toUsd(price)
+			jobs = append(jobs, job)
+		} else if IsProfitable(price, product.Title, product.Extra.PhaseTitle, GetDmarketLock(&product), sellerMargin) {
+			jobs = append(jobs, job)
+		}
 	}
+
+	dispatcher.Submit(jobs...)
 }
 
-func PurchaseProduct(product *DmarketProduct, marketType string) {
-	payload := fmt.Sprintf("{\"offers\": [{\"offerId\": \"%s\",\"price\": {\"amount\": \"%s\",\"currency\": \"USD\"},\"type\": \"%s\"}]}", product.Extra.OfferID, product.Price.USD, marketType)
-	response, err := SendSignedDmarketRequest(http.MethodPatch, "/exchange/v1/offers-buy", payload)
+// PurchaseProduct buys the offer of a job, returning whether the purchase went through
+func PurchaseProduct(job *PurchaseJob) bool {
+	product := &job.Product
+	marketType := job.MarketType
 
-	if err != nil {
-		fmt.Println(err)
-		ReportError(err)
-		return
+	recordType := marketType
+	if job.Flip {
+		recordType = "flip"
 	}
 
-	if response.StatusCode != 200 {
-		HandleError(response)
-		return
+	record := TradeRecord{
+		ID:       product.Extra.OfferID,
+		Market:   MARKET_DMARKET,
+		Type:     recordType,
+		Title:    product.Title,
+		Category: GetDmarketCategory(product),
+		Price:    GetDmarketPrice(product),
+		Source:   SOURCE_BOT,
+		SellerID: product.OwnerDetails.ID,
 	}
 
-	body, err := ioutil.ReadAll(response.Body)
-	response.Body.Close()
+	if err := risk.Reserve(record, GetBankroll()); err != nil {
+		InfoLogger.Println(err)
+		return false
+	}
+
+	if !balances.Reserve(record.ID, record.Price.Amount) {
+		InfoLogger.Println("Insufficient free balance for " + product.Title)
+		history.SetStatus(MARKET_DMARKET, record.ID, TRADE_FAILED)
+		return false
+	}
+
+	orderObj, err := dmarketClient.BuyOffers(PriorityContext(PRIORITY_PURCHASE), dmarket.BuyOffer{
+		OfferID: product.Extra.OfferID,
+		Price:   dmarket.OfferPrice{Amount: product.Price.USD, Currency: "USD"},
+		Type:    marketType,
+	})
+
+	var apiError *dmarket.Error
+	if errors.As(err, &apiError) && !apiError.IsServerError() {
+		failPurchase(record.ID)
+		ErrorLogger.Println(err)
+		ReportError(err)
+		return false
+	}
 
-	var orderObj DmarketOrderResponse
-	json.Unmarshal(body, &orderObj)
+	// The request may have reached Dmarket before failing, so the outcome has to be settled from the purchase history
+	if err != nil && orderObj.Body == "" {
+		fmt.Println(err)
+		go ReconcilePurchase(record, err.Error())
+		return false
+	}
 
-	if orderObj.Status == "TxSuccess" {
+	if orderObj.IsSuccessful() {
 		// Successful dmarket order
-		SendDmarketPurchase(product, marketType, orderObj.OrderID)
-	} else if orderObj.Status == "" && strings.Contains(string(body), "{\"started\":true}") {
-		// Successful p2p
-		SendDmarketPurchase(product, marketType, orderObj.OrderID)
+		balances.Commit(record.ID)
+		history.Update(MARKET_DMARKET, record.ID, func(bought *TradeRecord) {
+			bought.Status = TRADE_COMPLETED
+			bought.OrderID = orderObj.OrderID
+		})
+		if !job.Flip {
+			SendDmarketPurchase(product, marketType, orderObj.OrderID, job.PreviousPrice)
+		}
+		return true
+	} else if orderObj.IsP2PStarted() {
+		// Successful p2p, pending until the seller sends the trade
+		balances.Commit(record.ID)
+		history.Update(MARKET_DMARKET, record.ID, func(bought *TradeRecord) {
+			bought.OrderID = orderObj.OrderID
+		})
+		SendDmarketPurchase(product, marketType, orderObj.OrderID, job.PreviousPrice)
+		return true
 	} else if orderObj.DmOffersFailReason.Code == "OfferNotFound" {
 		// OOS
+		failPurchase(record.ID)
 		ReportError(errors.New("The following product was OOS at the time of purchase: " + product.Title))
 	} else {
-		ReportError(errors.New("Unknown order response: " + string(body)))
+		go ReconcilePurchase(record, "unknown order response: "+orderObj.Body)
 	}
 
-	UpdateAvailableBalance()
+	return false
 }
 
-func UpdateAvailableBalance() {
-	response, err := SendSignedDmarketRequest(http.MethodGet, "/account/v1/balance", "")
+func failPurchase(offerId string) {
+	balances.Release(offerId)
+	history.SetStatus(MARKET_DMARKET, offerId, TRADE_FAILED)
+}
 
-	if err != nil {
-		fmt.Println(err)
-		ReportError(err)
-		return
-	}
+func GetDmarketPrice(product *dmarket.Product) Money {
+	cents, _ := strconv.Atoi(product.Price.USD)
+	return MoneyFromCents(cents, USD)
+}
 
-	if response.StatusCode != 200 {
-		HandleError(response)
-		return
+// GetDmarketOfferType returns whether the offer is sold by the Dmarket bot ("dmarket") or another user ("p2p")
+func GetDmarketOfferType(product *dmarket.Product) string {
+	if product.Type == "p2p" {
+		return "p2p"
 	}
 
-	body, err := ioutil.ReadAll(response.Body)
-	response.Body.Close()
-
-	var balanceObj DmarketBalance
-	json.Unmarshal(body, &balanceObj)
-
-	balanceInt, _ := strconv.Atoi(balanceObj.Usd)
-	balance = float64(balanceInt) / 100
+	return "dmarket"
 }
 
-type DmarketOrderResponse struct {
-	TxID            string      `json:"txId"`
-	Status          string      `json:"status"`
-	OrderID         string      `json:"orderId"`
-	P2POffersStatus interface{} `json:"p2pOffersStatus"`
-	DmOffersStatus  struct {
-	} `json:"dmOffersStatus"`
-	DmOffersFailReason struct {
-		Code string `json:"code"`
-	} `json:"dmOffersFailReason"`
-}
+func GetDmarketCategory(product *dmarket.Product) string {
+	if product.Extra.ItemType != "" {
+		return product.Extra.ItemType
+	}
 
-type DmarketBalance struct {
-	Dmc                    string `json:"dmc"`
-	DmcAvailableToWithdraw string `json:"dmcAvailableToWithdraw"`
-	Usd                    string `json:"usd"`
-	UsdAvailableToWithdraw string `json:"usdAvailableToWithdraw"`
+	return product.Extra.Category
 }
 
-type DmarketProduct struct {
-	ItemID             string `json:"itemId"`
-	Type               string `json:"type"`
-	Amount             int    `json:"amount"`
-	ClassID            string `json:"classId"`
-	GameID             string `json:"gameId"`
-	GameType           string `json:"gameType"`
-	InMarket           bool   `json:"inMarket"`
-	LockStatus         bool   `json:"lockStatus"`
-	Title              string `json:"title"`
-	Description        string `json:"description"`
-	Image              string `json:"image"`
-	Slug               string `json:"slug"`
-	Owner              string `json:"owner"`
-	OwnersBlockchainID string `json:"ownersBlockchainId"`
-	OwnerDetails       struct {
-		ID     string `json:"id"`
-		Avatar string `json:"avatar"`
-		Wallet string `json:"wallet"`
-	} `json:"ownerDetails"`
-	Status   string `json:"status"`
-	Discount int    `json:"discount"`
-	Price    struct {
-		DMC string `json:"DMC"`
-		USD string `json:"USD"`
-	} `json:"price"`
-	InstantPrice struct {
-		DMC string `json:"DMC"`
-		USD string `json:"USD"`
-	} `json:"instantPrice"`
-	ExchangePrice struct {
-		DMC string `json:"DMC"`
-		USD string `json:"USD"`
-	} `json:"exchangePrice"`
-	InstantTargetID string `json:"instantTargetId"`
-	SuggestedPrice  struct {
-		DMC string `json:"DMC"`
-		USD string `json:"USD"`
-	} `json:"suggestedPrice"`
-	RecommendedPrice struct {
-		OfferPrice struct {
-			DMC string `json:"DMC"`
-			USD string `json:"USD"`
-		} `json:"offerPrice"`
-		D3 struct {
-			DMC string `json:"DMC"`
-			USD string `json:"USD"`
-		} `json:"d3"`
-		D7 struct {
-			DMC string `json:"DMC"`
-			USD string `json:"USD"`
-		} `json:"d7"`
-		D7Plus struct {
-			DMC string `json:"DMC"`
-			USD string `json:"USD"`
-		} `json:"d7Plus"`
-	} `json:"recommendedPrice"`
-	Extra struct {
-		NameColor         string   `json:"nameColor"`
-		BackgroundColor   string   `json:"backgroundColor"`
-		Tradable          bool     `json:"tradable"`
-		OfferID           string   `json:"offerId"`
-		IsNew             bool     `json:"isNew"`
-		GameID            string   `json:"gameId"`
-		Name              string   `json:"name"`
-		CategoryPath      string   `json:"categoryPath"`
-		LinkID            string   `json:"linkId"`
-		Exterior          string   `json:"exterior"`
-		Quality           string   `json:"quality"`
-		Category          string   `json:"category"`
-		TradeLockDuration int      `json:"tradeLockDuration"`
-		ItemType          string   `json:"itemType"`
-		InspectInGame     string   `json:"inspectInGame"`
-		Collection        []string `json:"collection"`
-		SaleRestricted    bool     `json:"saleRestricted"`
-		InGameAssetID     string   `json:"inGameAssetID"`
-		EmissionSerial    string   `json:"emissionSerial"`
-		PhaseTitle        string   `json:"phaseTitle"`
-	} `json:"extra"`
-	CreatedAt     int `json:"createdAt"`
-	DeliveryStats struct {
-		Rate string `json:"rate"`
-		Time string `json:"time"`
-	} `json:"deliveryStats"`
-	Fees struct {
-		F2F struct {
-			Sell struct {
-				Default struct {
-					Percentage string `json:"percentage"`
-					MinFee     struct {
-						DMC string `json:"DMC"`
-						USD string `json:"USD"`
-					} `json:"minFee"`
-				} `json:"default"`
-			} `json:"sell"`
-			InstantSell struct {
-				Default struct {
-					Percentage string `json:"percentage"`
-					MinFee     struct {
-						DMC string `json:"DMC"`
-						USD string `json:"USD"`
-					} `json:"minFee"`
-				} `json:"default"`
-			} `json:"instantSell"`
-			Exchange struct {
-				Default struct {
-					Percentage string `json:"percentage"`
-					MinFee     struct {
-						DMC string `json:"DMC"`
-						USD string `json:"USD"`
-					} `json:"minFee"`
-				} `json:"default"`
-			} `json:"exchange"`
-		} `json:"f2f"`
-		Dmarket struct {
-			Sell struct {
-				Default struct {
-					Percentage string `json:"percentage"`
-					MinFee     struct {
-						DMC string `json:"DMC"`
-						USD string `json:"USD"`
-					} `json:"minFee"`
-				} `json:"default"`
-			} `json:"sell"`
-			InstantSell struct {
-				Default struct {
-					Percentage string `json:"percentage"`
-					MinFee     struct {
-						DMC string `json:"DMC"`
-						USD string `json:"USD"`
-					} `json:"minFee"`
-				} `json:"default"`
-			} `json:"instantSell"`
-			Exchange struct {
-				Default struct {
-					Percentage string `json:"percentage"`
-					MinFee     struct {
-						DMC string `json:"DMC"`
-						USD string `json:"USD"`
-					} `json:"minFee"`
-				} `json:"default"`
-			} `json:"exchange"`
-		} `json:"dmarket"`
-	} `json:"fees"`
-	DiscountPrice struct {
-		DMC string `json:"DMC"`
-		USD string `json:"USD"`
-	} `json:"discountPrice"`
-	ProductID string `json:"productId"`
-}
+func UpdateAvailableBalance() {
+	token := balances.BeginReconcile()
+	balanceObj, err := dmarketClient.Balance(PriorityContext(PRIORITY_ACCOUNT))
 
-type DmarketProductsResponse struct {
-	Objects []DmarketProduct `json:"objects"`
-	Total   struct {
-		Offers          int `json:"offers"`
-		Targets         int `json:"targets"`
-		Items           int `json:"items"`
-		CompletedOffers int `json:"completedOffers"`
-		ClosedTargets   int `json:"closedTargets"`
-	} `json:"total"`
-	Cursor string `json:"cursor"`
+	if err != nil {
+		fmt.Println(err)
+		ReportError(err)
+		return
+	}
+
+	balanceInt, _ := strconv.Atoi(balanceObj.Usd)
+	if !balances.Reconcile(float64(balanceInt)/100, token) {
+		InfoLogger.Println("Purchase completed while fetching balance, skipping reconcile")
+	}
 }
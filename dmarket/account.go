@@ -0,0 +1,58 @@
+package dmarket
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+const BALANCE_PATH = "/account/v1/balance"
+const INVENTORY_PATH = "/marketplace-api/v1/user-inventory"
+
+// Balance amounts are in cents
+type Balance struct {
+	Dmc                    string `json:"dmc"`
+	DmcAvailableToWithdraw string `json:"dmcAvailableToWithdraw"`
+	Usd                    string `json:"usd"`
+	UsdAvailableToWithdraw string `json:"usdAvailableToWithdraw"`
+}
+
+func (c *Client) Balance(ctx context.Context) (Balance, error) {
+	var balanceObj Balance
+	err := c.request(ctx, http.MethodGet, BALANCE_PATH, nil, true, &balanceObj)
+
+	return balanceObj, err
+}
+
+type InventoryItem struct {
+	ItemID   string `json:"ItemID"`
+	AssetID  string `json:"AssetID"`
+	Title    string `json:"Title"`
+	Tradable bool   `json:"Tradable"`
+	InMarket bool   `json:"InMarket"`
+	Offer    struct {
+		OfferID string `json:"OfferID"`
+		Price   Price  `json:"Price"`
+	} `json:"Offer"`
+}
+
+type InventoryResponse struct {
+	Items  []InventoryItem `json:"Items"`
+	Total  string          `json:"Total"`
+	Cursor string          `json:"Cursor"`
+}
+
+// Inventory fetches one page of our inventory, including items already listed
+func (c *Client) Inventory(ctx context.Context, cursor string) (InventoryResponse, error) {
+	query := url.Values{}
+	query.Set("GameID", CSGO_GAME_ID)
+	query.Set("Limit", "100")
+	if cursor != "" {
+		query.Set("Cursor", cursor)
+	}
+
+	var inventoryObj InventoryResponse
+	err := c.request(ctx, http.MethodGet, INVENTORY_PATH+"?"+query.Encode(), nil, true, &inventoryObj)
+
+	return inventoryObj, err
+}
@@ -0,0 +1,144 @@
+// Package dmarket is a client for the signed Dmarket trading API
+package dmarket
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DEFAULT_BASE_URL = "https://api.dmarket.com"
+const CSGO_GAME_ID = "a8db"
+
+// Limiter paces the requests of a client, e.g. to share one rate limit between several callers
+type Limiter interface {
+	// Wait blocks until a request may be sent, or the context is done
+	Wait(ctx context.Context) error
+	// Observe reports the outcome of a request
+	Observe(response *http.Response, err error)
+}
+
+type Client struct {
+	publicKey  string
+	privateKey ed25519.PrivateKey
+	baseUrl    string
+	httpClient *http.Client
+	// Optional, requests are sent as soon as they are made without one
+	Limiter Limiter
+	// Corrects the signing timestamp for the offset of the local clock from Dmarket's
+	Clock *Clock
+}
+
+// NewClient creates a client signing with the given hex encoded API keys
+func NewClient(publicKey string, privateKey string, baseUrl string, httpClient *http.Client) *Client {
+	if baseUrl == "" {
+		baseUrl = DEFAULT_BASE_URL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	key, _ := hex.DecodeString(privateKey)
+	if len(key) > ed25519.PrivateKeySize {
+		key = key[:ed25519.PrivateKeySize]
+	}
+
+	return &Client{
+		publicKey:  publicKey,
+		privateKey: ed25519.PrivateKey(key),
+		baseUrl:    baseUrl,
+		httpClient: httpClient,
+		Clock:      &Clock{},
+	}
+}
+
+func (c *Client) sign(message string) string {
+	if len(c.privateKey) != ed25519.PrivateKeySize {
+		return ""
+	}
+
+	return hex.EncodeToString(ed25519.Sign(c.privateKey, []byte(message)))
+}
+
+// Send sends a request, signing it when signed is set, and returns the raw response
+func (c *Client) Send(ctx context.Context, method string, path string, body string, signed bool) (*http.Response, error) {
+	// Signed only once the limiter lets the request through, so waiting can't age the timestamp
+	if c.Limiter != nil {
+		if err := c.Limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseUrl+path, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	if signed {
+		timestamp := strconv.FormatInt(c.Clock.Now().UTC().Unix(), 10)
+		req.Header.Set("X-Sign-Date", timestamp)
+		req.Header.Set("X-Request-Sign", "dmar ed25519 "+c.sign(method+path+body+timestamp))
+		req.Header.Set("X-Api-Key", c.publicKey)
+	}
+
+	sent := time.Now()
+	response, err := c.httpClient.Do(req)
+
+	if c.Limiter != nil {
+		c.Limiter.Observe(response, err)
+	}
+	if err == nil {
+		c.Clock.Observe(response, sent)
+	}
+
+	return response, err
+}
+
+// request sends a request with a JSON encoded body and decodes a successful response into out
+func (c *Client) request(ctx context.Context, method string, path string, body interface{}, signed bool, out interface{}) error {
+	payload := ""
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = string(encoded)
+	}
+
+	response, err := c.Send(ctx, method, path, payload, signed)
+	if err != nil {
+		return err
+	}
+
+	responseBody, err := c.readResponse(response)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(responseBody, out)
+}
+
+// readResponse reads and closes the body of a response, returning an error for unsuccessful ones
+func (c *Client) readResponse(response *http.Response) ([]byte, error) {
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return body, newError(response.StatusCode, body, c.Clock.Offset())
+	}
+
+	return body, nil
+}
@@ -0,0 +1,62 @@
+package dmarket
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Clock tracks how far the local clock is from Dmarket's, measured from the Date header of their responses
+type Clock struct {
+	mutex    sync.Mutex
+	offset   time.Duration
+	measured bool
+	skewed   bool
+	// OnSkew is called when the offset first exceeds MaxSkew, 0 disables it
+	MaxSkew time.Duration
+	OnSkew  func(offset time.Duration)
+}
+
+// Now returns the local time corrected to Dmarket's clock
+func (c *Clock) Now() time.Time {
+	return time.Now().Add(c.Offset())
+}
+
+func (c *Clock) Offset() time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.offset
+}
+
+// Observe measures the offset from a response to a request sent at the given time
+func (c *Clock) Observe(response *http.Response, sent time.Time) {
+	received := time.Now()
+
+	date, err := http.ParseTime(response.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+
+	// The Date header is truncated to the second, so the server time lies half a second after it on average.
+	// It was stamped somewhere between sending and receiving, taken as the midpoint.
+	midpoint := sent.Add(received.Sub(sent) / 2)
+	offset := date.Add(500 * time.Millisecond).Sub(midpoint)
+
+	c.mutex.Lock()
+	if c.measured {
+		// Smooth out network jitter between measurements
+		offset = (c.offset*3 + offset) / 4
+	}
+	c.offset = offset
+	c.measured = true
+
+	skewed := c.MaxSkew > 0 && (offset > c.MaxSkew || offset < -c.MaxSkew)
+	notify := skewed && !c.skewed && c.OnSkew != nil
+	c.skewed = skewed
+	c.mutex.Unlock()
+
+	if notify {
+		c.OnSkew(offset)
+	}
+}
@@ -0,0 +1,66 @@
+package dmarket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Error is a failed response from Dmarket, decoded from its error body
+type Error struct {
+	StatusCode int    `json:"-"`
+	Kind       string `json:"error"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+	Details    []struct {
+		TypeURL string `json:"type_url"`
+		Value   string `json:"value"`
+	} `json:"details"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Erroneous response received: %d %s", e.StatusCode, e.Message)
+}
+
+// IsServerError reports whether Dmarket failed on its side, in which case the request may still have gone through
+func (e *Error) IsServerError() bool {
+	return e.StatusCode >= 500
+}
+
+// SignatureError is returned when Dmarket rejects the signature of a request, most often due to a skewed clock
+type SignatureError struct {
+	API *Error
+	// Offset of the local clock from Dmarket's when the request was rejected
+	Offset time.Duration
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("Dmarket rejected the request signature: %d %s (clock offset %s)", e.API.StatusCode, e.API.Message, e.Offset.Round(time.Millisecond))
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.API
+}
+
+func isSignatureRejection(apiError *Error) bool {
+	if apiError.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+
+	text := strings.ToLower(apiError.Kind + " " + apiError.Message)
+	return apiError.StatusCode == http.StatusForbidden && (strings.Contains(text, "sign") || strings.Contains(text, "expired"))
+}
+
+func newError(statusCode int, body []byte, offset time.Duration) error {
+	apiError := &Error{}
+	json.Unmarshal(body, apiError)
+	apiError.StatusCode = statusCode
+
+	if isSignatureRejection(apiError) {
+		return &SignatureError{API: apiError, Offset: offset}
+	}
+
+	return apiError
+}
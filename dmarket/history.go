@@ -0,0 +1,68 @@
+package dmarket
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const HISTORY_PATH = "/exchange/v1/history"
+const P2P_TRADES_PATH = "/exchange/v1/p2p/trades"
+
+type HistoryEntry struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Action    string `json:"action"`
+	Subject   string `json:"subject"`
+	Status    string `json:"status"`
+	OfferID   string `json:"offerId"`
+	OrderID   string `json:"orderId"`
+	CreatedAt int64  `json:"createdAt"`
+	Changes   []struct {
+		Money struct {
+			Amount   string `json:"amount"`
+			Currency string `json:"currency"`
+		} `json:"money"`
+		ChangeType string `json:"changeType"`
+	} `json:"changes"`
+}
+
+type HistoryResponse struct {
+	Objects []HistoryEntry `json:"objects"`
+	Total   int            `json:"total"`
+}
+
+// History fetches a page of our trade history, filtered by comma separated activities such as "purchase,sell"
+func (c *Client) History(ctx context.Context, activities string, offset int, limit int) (HistoryResponse, error) {
+	query := url.Values{}
+	query.Set("activities", activities)
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(limit))
+
+	var historyObj HistoryResponse
+	err := c.request(ctx, http.MethodGet, HISTORY_PATH+"?"+query.Encode(), nil, true, &historyObj)
+
+	return historyObj, err
+}
+
+type P2PTrade struct {
+	OfferID string `json:"offerId"`
+	OrderID string `json:"orderId"`
+	Status  string `json:"status"`
+}
+
+type P2PTradesResponse struct {
+	Trades []P2PTrade `json:"trades"`
+}
+
+// P2PTrades fetches the P2P trades of an offer we bought
+func (c *Client) P2PTrades(ctx context.Context, offerId string) (P2PTradesResponse, error) {
+	query := url.Values{}
+	query.Set("offerId", offerId)
+
+	var tradesObj P2PTradesResponse
+	err := c.request(ctx, http.MethodGet, P2P_TRADES_PATH+"?"+query.Encode(), nil, true, &tradesObj)
+
+	return tradesObj, err
+}
@@ -0,0 +1,315 @@
+package dmarket
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const ITEMS_PATH = "/exchange/v1/market/items"
+
+// ItemsQuery builds the filters for Dmarket's market items endpoint, so filtering is done server side
+type ItemsQuery struct {
+	Title string
+	// Price range in USD cents, 0 for no bound
+	PriceFrom   int
+	PriceTo     int
+	TreeFilters map[string][]string
+	Types       []string
+	OrderBy     string
+	OrderDir    string
+	Limit       int
+	Cursor      string
+}
+
+func NewItemsQuery() *ItemsQuery {
+	return &ItemsQuery{
+		TreeFilters: make(map[string][]string),
+		OrderBy:     "updated",
+		OrderDir:    "desc",
+		Limit:       100,
+	}
+}
+
+func (q *ItemsQuery) WithTitle(title string) *ItemsQuery {
+	q.Title = title
+	return q
+}
+
+func (q *ItemsQuery) WithPriceRange(fromCents int, toCents int) *ItemsQuery {
+	q.PriceFrom = fromCents
+	q.PriceTo = toCents
+	return q
+}
+
+func (q *ItemsQuery) WithTreeFilter(key string, values ...string) *ItemsQuery {
+	q.TreeFilters[key] = append(q.TreeFilters[key], values...)
+	return q
+}
+
+func (q *ItemsQuery) WithCategory(categories ...string) *ItemsQuery {
+	return q.WithTreeFilter("category_0", categories...)
+}
+
+func (q *ItemsQuery) WithExterior(exteriors ...string) *ItemsQuery {
+	return q.WithTreeFilter("exterior", exteriors...)
+}
+
+func (q *ItemsQuery) WithPhase(phases ...string) *ItemsQuery {
+	return q.WithTreeFilter("phase", phases...)
+}
+
+func (q *ItemsQuery) WithTypes(types ...string) *ItemsQuery {
+	q.Types = append(q.Types, types...)
+	return q
+}
+
+func (q *ItemsQuery) WithOrder(orderBy string, orderDir string) *ItemsQuery {
+	q.OrderBy = orderBy
+	q.OrderDir = orderDir
+	return q
+}
+
+func (q *ItemsQuery) WithLimit(limit int) *ItemsQuery {
+	q.Limit = limit
+	return q
+}
+
+// WithCursor returns a copy of the query for the page at cursor, leaving the original unchanged
+func (q ItemsQuery) WithCursor(cursor string) *ItemsQuery {
+	q.Cursor = cursor
+	return &q
+}
+
+func (q *ItemsQuery) encodeTreeFilters() string {
+	keys := make([]string, 0, len(q.TreeFilters))
+	for key := range q.TreeFilters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var filters []string
+	for _, key := range keys {
+		for _, value := range q.TreeFilters[key] {
+			filters = append(filters, key+"[]="+value)
+		}
+	}
+
+	return strings.Join(filters, ",")
+}
+
+func (q *ItemsQuery) Encode() string {
+	values := url.Values{}
+	values.Set("side", "market")
+	values.Set("gameId", CSGO_GAME_ID)
+	values.Set("currency", "USD")
+	values.Set("orderBy", q.OrderBy)
+	values.Set("orderDir", q.OrderDir)
+	values.Set("limit", strconv.Itoa(q.Limit))
+
+	if q.Title != "" {
+		values.Set("title", q.Title)
+	}
+	if q.PriceFrom > 0 {
+		values.Set("priceFrom", strconv.Itoa(q.PriceFrom))
+	}
+	if q.PriceTo > 0 {
+		values.Set("priceTo", strconv.Itoa(q.PriceTo))
+	}
+	if len(q.TreeFilters) > 0 {
+		values.Set("treeFilters", q.encodeTreeFilters())
+	}
+	if len(q.Types) > 0 {
+		values.Set("types", strings.Join(q.Types, ","))
+	}
+	if q.Cursor != "" {
+		values.Set("cursor", q.Cursor)
+	}
+
+	return values.Encode()
+}
+
+// MarketItems fetches one page of market listings matching the query
+func (c *Client) MarketItems(ctx context.Context, query *ItemsQuery) (ProductsResponse, error) {
+	var productsObj ProductsResponse
+	err := c.request(ctx, http.MethodGet, ITEMS_PATH+"?"+query.Encode(), nil, false, &productsObj)
+
+	return productsObj, err
+}
+
+type Product struct {
+	ItemID             string `json:"itemId"`
+	Type               string `json:"type"`
+	Amount             int    `json:"amount"`
+	ClassID            string `json:"classId"`
+	GameID             string `json:"gameId"`
+	GameType           string `json:"gameType"`
+	InMarket           bool   `json:"inMarket"`
+	LockStatus         bool   `json:"lockStatus"`
+	Title              string `json:"title"`
+	Description        string `json:"description"`
+	Image              string `json:"image"`
+	Slug               string `json:"slug"`
+	Owner              string `json:"owner"`
+	OwnersBlockchainID string `json:"ownersBlockchainId"`
+	OwnerDetails       struct {
+		ID     string `json:"id"`
+		Avatar string `json:"avatar"`
+		Wallet string `json:"wallet"`
+	} `json:"ownerDetails"`
+	Status   string `json:"status"`
+	Discount int    `json:"discount"`
+	Price    struct {
+		DMC string `json:"DMC"`
+		USD string `json:"USD"`
+	} `json:"price"`
+	InstantPrice struct {
+		DMC string `json:"DMC"`
+		USD string `json:"USD"`
+	} `json:"instantPrice"`
+	ExchangePrice struct {
+		DMC string `json:"DMC"`
+		USD string `json:"USD"`
+	} `json:"exchangePrice"`
+	InstantTargetID string `json:"instantTargetId"`
+	SuggestedPrice  struct {
+		DMC string `json:"DMC"`
+		USD string `json:"USD"`
+	} `json:"suggestedPrice"`
+	RecommendedPrice struct {
+		OfferPrice struct {
+			DMC string `json:"DMC"`
+			USD string `json:"USD"`
+		} `json:"offerPrice"`
+		D3 struct {
+			DMC string `json:"DMC"`
+			USD string `json:"USD"`
+		} `json:"d3"`
+		D7 struct {
+			DMC string `json:"DMC"`
+			USD string `json:"USD"`
+		} `json:"d7"`
+		D7Plus struct {
+			DMC string `json:"DMC"`
+			USD string `json:"USD"`
+		} `json:"d7Plus"`
+	} `json:"recommendedPrice"`
+	Extra struct {
+		NameColor         string   `json:"nameColor"`
+		BackgroundColor   string   `json:"backgroundColor"`
+		Tradable          bool     `json:"tradable"`
+		OfferID           string   `json:"offerId"`
+		IsNew             bool     `json:"isNew"`
+		GameID            string   `json:"gameId"`
+		Name              string   `json:"name"`
+		CategoryPath      string   `json:"categoryPath"`
+		LinkID            string   `json:"linkId"`
+		Exterior          string   `json:"exterior"`
+		Quality           string   `json:"quality"`
+		Category          string   `json:"category"`
+		TradeLockDuration int      `json:"tradeLockDuration"`
+		ItemType          string   `json:"itemType"`
+		InspectInGame     string   `json:"inspectInGame"`
+		Collection        []string `json:"collection"`
+		SaleRestricted    bool     `json:"saleRestricted"`
+		InGameAssetID     string   `json:"inGameAssetID"`
+		EmissionSerial    string   `json:"emissionSerial"`
+		PhaseTitle        string   `json:"phaseTitle"`
+	} `json:"extra"`
+	CreatedAt int `json:"createdAt"`
+	// Rate is the percentage of the seller's P2P trades delivered and Time their usual delivery time in minutes
+	DeliveryStats struct {
+		Rate string `json:"rate"`
+		Time string `json:"time"`
+	} `json:"deliveryStats"`
+	Fees struct {
+		F2F struct {
+			Sell struct {
+				Default struct {
+					Percentage string `json:"percentage"`
+					MinFee     struct {
+						DMC string `json:"DMC"`
+						USD string `json:"USD"`
+					} `json:"minFee"`
+				} `json:"default"`
+			} `json:"sell"`
+			InstantSell struct {
+				Default struct {
+					Percentage string `json:"percentage"`
+					MinFee     struct {
+						DMC string `json:"DMC"`
+						USD string `json:"USD"`
+					} `json:"minFee"`
+				} `json:"default"`
+			} `json:"instantSell"`
+			Exchange struct {
+				Default struct {
+					Percentage string `json:"percentage"`
+					MinFee     struct {
+						DMC string `json:"DMC"`
+						USD string `json:"USD"`
+					} `json:"minFee"`
+				} `json:"default"`
+			} `json:"exchange"`
+		} `json:"f2f"`
+		Dmarket struct {
+			Sell struct {
+				Default struct {
+					Percentage string `json:"percentage"`
+					MinFee     struct {
+						DMC string `json:"DMC"`
+						USD string `json:"USD"`
+					} `json:"minFee"`
+				} `json:"default"`
+			} `json:"sell"`
+			InstantSell struct {
+				Default struct {
+					Percentage string `json:"percentage"`
+					MinFee     struct {
+						DMC string `json:"DMC"`
+						USD string `json:"USD"`
+					} `json:"minFee"`
+				} `json:"default"`
+			} `json:"instantSell"`
+			Exchange struct {
+				Default struct {
+					Percentage string `json:"percentage"`
+					MinFee     struct {
+						DMC string `json:"DMC"`
+						USD string `json:"USD"`
+					} `json:"minFee"`
+				} `json:"default"`
+			} `json:"exchange"`
+		} `json:"dmarket"`
+	} `json:"fees"`
+	DiscountPrice struct {
+		DMC string `json:"DMC"`
+		USD string `json:"USD"`
+	} `json:"discountPrice"`
+	ProductID string `json:"productId"`
+}
+
+type ProductsResponse struct {
+	Objects []Product `json:"objects"`
+	Total   struct {
+		Offers          int `json:"offers"`
+		Targets         int `json:"targets"`
+		Items           int `json:"items"`
+		CompletedOffers int `json:"completedOffers"`
+		ClosedTargets   int `json:"closedTargets"`
+	} `json:"total"`
+	Cursor string `json:"cursor"`
+}
+
+// DeliveryRate returns the share, from 0 to 1, of the seller's P2P trades delivered, or false if unknown
+func (p *Product) DeliveryRate() (float64, bool) {
+	percentage, err := strconv.ParseFloat(p.DeliveryStats.Rate, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return percentage / 100, true
+}
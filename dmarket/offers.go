@@ -0,0 +1,132 @@
+package dmarket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const BUY_OFFERS_PATH = "/exchange/v1/offers-buy"
+const USER_OFFERS_PATH = "/marketplace-api/v1/user-offers"
+
+// OfferPrice is a price in cents, as used by the exchange endpoints
+type OfferPrice struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type BuyOffer struct {
+	OfferID string     `json:"offerId"`
+	Price   OfferPrice `json:"price"`
+	// "dmarket" or "p2p"
+	Type string `json:"type"`
+}
+
+type BuyOffersRequest struct {
+	Offers []BuyOffer `json:"offers"`
+}
+
+type OrderResponse struct {
+	TxID            string      `json:"txId"`
+	Status          string      `json:"status"`
+	OrderID         string      `json:"orderId"`
+	P2POffersStatus interface{} `json:"p2pOffersStatus"`
+	DmOffersStatus  struct {
+	} `json:"dmOffersStatus"`
+	DmOffersFailReason struct {
+		Code string `json:"code"`
+	} `json:"dmOffersFailReason"`
+	// Raw response body, as P2P purchases only report they started
+	Body string `json:"-"`
+}
+
+// IsSuccessful reports whether a purchase from Dmarket's own stock went through
+func (o *OrderResponse) IsSuccessful() bool {
+	return o.Status == "TxSuccess"
+}
+
+// IsP2PStarted reports whether a P2P purchase started, pending until the seller sends the trade
+func (o *OrderResponse) IsP2PStarted() bool {
+	return o.Status == "" && strings.Contains(o.Body, "{\"started\":true}")
+}
+
+// BuyOffers buys market offers. A failed request may still have gone through, so its outcome should be checked in the history.
+func (c *Client) BuyOffers(ctx context.Context, offers ...BuyOffer) (OrderResponse, error) {
+	var orderObj OrderResponse
+
+	payload, err := json.Marshal(BuyOffersRequest{Offers: offers})
+	if err != nil {
+		return orderObj, err
+	}
+
+	response, err := c.Send(ctx, http.MethodPatch, BUY_OFFERS_PATH, string(payload), true)
+	if err != nil {
+		return orderObj, err
+	}
+
+	body, err := c.readResponse(response)
+	if err != nil {
+		return orderObj, err
+	}
+
+	orderObj.Body = string(body)
+	err = json.Unmarshal(body, &orderObj)
+
+	return orderObj, err
+}
+
+// Price is a price in dollars, as used by the marketplace endpoints
+type Price struct {
+	Currency string  `json:"Currency"`
+	Amount   float64 `json:"Amount"`
+}
+
+type UserOffer struct {
+	OfferID string `json:"OfferID,omitempty"`
+	AssetID string `json:"AssetID"`
+	Price   Price  `json:"Price"`
+}
+
+type UserOffersRequest struct {
+	Offers []UserOffer `json:"Offers"`
+}
+
+type UserOffersResult struct {
+	Result []struct {
+		OfferID    string `json:"OfferID"`
+		Successful bool   `json:"Successful"`
+		Error      struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+	} `json:"Result"`
+}
+
+// Err returns the error of the first unsuccessful offer
+func (r *UserOffersResult) Err() error {
+	for _, result := range r.Result {
+		if !result.Successful {
+			return errors.New(result.Error.Message)
+		}
+	}
+
+	return nil
+}
+
+// CreateOffers lists inventory items for sale
+func (c *Client) CreateOffers(ctx context.Context, offers ...UserOffer) (UserOffersResult, error) {
+	var resultObj UserOffersResult
+	err := c.request(ctx, http.MethodPost, USER_OFFERS_PATH+"/create", UserOffersRequest{Offers: offers}, true, &resultObj)
+
+	return resultObj, err
+}
+
+// EditOffers reprices listed items
+func (c *Client) EditOffers(ctx context.Context, offers ...UserOffer) (UserOffersResult, error) {
+	var resultObj UserOffersResult
+	err := c.request(ctx, http.MethodPost, USER_OFFERS_PATH+"/edit", UserOffersRequest{Offers: offers}, true, &resultObj)
+
+	return resultObj, err
+}
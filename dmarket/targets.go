@@ -0,0 +1,185 @@
+package dmarket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+const TARGETS_PATH = "/marketplace-api/v1/user-targets"
+const TARGETS_SELL_PATH = "/exchange/v1/targets-sell"
+
+type Target struct {
+	TargetID string `json:"TargetID"`
+	Title    string `json:"Title"`
+	Amount   int    `json:"Amount"`
+	Status   string `json:"Status"`
+	Price    Price  `json:"Price"`
+}
+
+type TargetsResponse struct {
+	Items  []Target `json:"Items"`
+	Total  string   `json:"Total"`
+	Cursor string   `json:"Cursor"`
+}
+
+// Statuses of closed targets. Trade protected fills can still be reverted by the seller for a few days.
+const (
+	CLOSED_TARGET_SUCCESSFUL      = "successful"
+	CLOSED_TARGET_TRADE_PROTECTED = "trade_protected"
+	CLOSED_TARGET_REVERTED        = "reverted"
+)
+
+type ClosedTarget struct {
+	TargetID string `json:"TargetID"`
+	OfferID  string `json:"OfferID"`
+	Title    string `json:"Title"`
+	Price    Price  `json:"Price"`
+	Status   string `json:"Status"`
+	ClosedAt string `json:"ClosedAt"`
+}
+
+// IsFilled reports whether the target bought an item, as opposed to being cancelled or reverted
+func (t *ClosedTarget) IsFilled() bool {
+	return t.Status == CLOSED_TARGET_SUCCESSFUL || t.Status == CLOSED_TARGET_TRADE_PROTECTED
+}
+
+type ClosedTargetsResponse struct {
+	Trades []ClosedTarget `json:"Trades"`
+	Total  string         `json:"Total"`
+	Cursor string         `json:"Cursor"`
+}
+
+type NewTarget struct {
+	Amount int    `json:"Amount"`
+	Price  Price  `json:"Price"`
+	Title  string `json:"Title"`
+}
+
+type CreateTargetsRequest struct {
+	GameID  string      `json:"GameID"`
+	Targets []NewTarget `json:"Targets"`
+}
+
+type TargetRef struct {
+	TargetID string `json:"TargetID"`
+}
+
+type DeleteTargetsRequest struct {
+	Targets []TargetRef `json:"Targets"`
+}
+
+type TargetsResult struct {
+	Result []struct {
+		TargetID   string `json:"TargetID"`
+		Successful bool   `json:"Successful"`
+		Error      struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+	} `json:"Result"`
+}
+
+// Err returns the error of the first unsuccessful target
+func (r *TargetsResult) Err() error {
+	for _, result := range r.Result {
+		if !result.Successful {
+			return errors.New(result.Error.Message)
+		}
+	}
+
+	return nil
+}
+
+// Targets fetches one page of our targets with the given status, e.g. "TargetStatusActive"
+func (c *Client) Targets(ctx context.Context, status string, cursor string) (TargetsResponse, error) {
+	query := url.Values{}
+	query.Set("GameID", CSGO_GAME_ID)
+	query.Set("BasicFilters.Status", status)
+	query.Set("Limit", "100")
+	if cursor != "" {
+		query.Set("Cursor", cursor)
+	}
+
+	var targetsObj TargetsResponse
+	err := c.request(ctx, http.MethodGet, TARGETS_PATH+"?"+query.Encode(), nil, true, &targetsObj)
+
+	return targetsObj, err
+}
+
+// ClosedTargets fetches one page of closed targets, newest first
+func (c *Client) ClosedTargets(ctx context.Context, cursor string) (ClosedTargetsResponse, error) {
+	query := url.Values{}
+	query.Set("Limit", "100")
+	query.Set("OrderDir", "desc")
+	if cursor != "" {
+		query.Set("Cursor", cursor)
+	}
+
+	var closedObj ClosedTargetsResponse
+	err := c.request(ctx, http.MethodGet, TARGETS_PATH+"/closed?"+query.Encode(), nil, true, &closedObj)
+
+	return closedObj, err
+}
+
+func (c *Client) CreateTargets(ctx context.Context, targets ...NewTarget) (TargetsResult, error) {
+	request := CreateTargetsRequest{GameID: CSGO_GAME_ID, Targets: targets}
+
+	var resultObj TargetsResult
+	err := c.request(ctx, http.MethodPost, TARGETS_PATH+"/create", request, true, &resultObj)
+
+	return resultObj, err
+}
+
+func (c *Client) DeleteTargets(ctx context.Context, targetIds ...string) (TargetsResult, error) {
+	var request DeleteTargetsRequest
+	for _, targetId := range targetIds {
+		request.Targets = append(request.Targets, TargetRef{TargetID: targetId})
+	}
+
+	var resultObj TargetsResult
+	err := c.request(ctx, http.MethodPost, TARGETS_PATH+"/delete", request, true, &resultObj)
+
+	return resultObj, err
+}
+
+type TargetSell struct {
+	TargetID string     `json:"targetId"`
+	ItemID   string     `json:"itemId"`
+	Price    OfferPrice `json:"price"`
+}
+
+type TargetSellRequest struct {
+	Targets []TargetSell `json:"targets"`
+}
+
+type TargetSellResponse struct {
+	Result []struct {
+		TargetID   string `json:"targetId"`
+		Successful bool   `json:"successful"`
+		Error      struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"result"`
+}
+
+// Err returns the error of the first unsuccessful sale
+func (r *TargetSellResponse) Err() error {
+	for _, result := range r.Result {
+		if !result.Successful {
+			return errors.New(result.Error.Message)
+		}
+	}
+
+	return nil
+}
+
+// SellToTargets sells our items straight into other users' targets
+func (c *Client) SellToTargets(ctx context.Context, sells ...TargetSell) (TargetSellResponse, error) {
+	var sellObj TargetSellResponse
+	err := c.request(ctx, http.MethodPatch, TARGETS_SELL_PATH, TargetSellRequest{Targets: sells}, true, &sellObj)
+
+	return sellObj, err
+}
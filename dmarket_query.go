@@ -0,0 +1,60 @@
+package main
+
+import "csgoTrader/dmarket"
+
+// DmarketQueryRule configures one Dmarket poller, prices are in USD and default to the global range
+type DmarketQueryRule struct {
+	Title        string   `json:"title"`
+	Categories   []string `json:"categories"`
+	Exteriors    []string `json:"exteriors"`
+	Phases       []string `json:"phases"`
+	Types        []string `json:"types"`
+	OrderBy      string   `json:"orderBy"`
+	Limit        int      `json:"limit"`
+	MinimumPrice float64  `json:"minimumPrice"`
+	MaximumPrice float64  `json:"maximumPrice"`
+}
+
+func (r *DmarketQueryRule) Query() *dmarket.ItemsQuery {
+	minimumPrice, maximumPrice := r.MinimumPrice, r.MaximumPrice
+	if minimumPrice == 0 {
+		minimumPrice = config.MinimumPrice
+	}
+	if maximumPrice == 0 {
+		maximumPrice = config.MaximumPrice
+	}
+
+	query := dmarket.NewItemsQuery().
+		WithTitle(r.Title).
+		WithPriceRange(NewMoney(minimumPrice, USD).Cents(), NewMoney(maximumPrice, USD).Cents())
+
+	if len(r.Types) > 0 {
+		query.WithTypes(r.Types...)
+	}
+	if len(r.Categories) > 0 {
+		query.WithCategory(r.Categories...)
+	}
+	if len(r.Exteriors) > 0 {
+		query.WithExterior(r.Exteriors...)
+	}
+	if len(r.Phases) > 0 {
+		query.WithPhase(r.Phases...)
+	}
+	if r.OrderBy != "" {
+		query.WithOrder(r.OrderBy, "desc")
+	}
+	if r.Limit > 0 {
+		query.WithLimit(r.Limit)
+	}
+
+	return query
+}
+
+// GetDmarketQueryRules returns the configured rules, or a single rule for both offer types covering the global price range
+func GetDmarketQueryRules() []DmarketQueryRule {
+	if len(config.DmarketQueries) > 0 {
+		return config.DmarketQueries
+	}
+
+	return []DmarketQueryRule{{Types: []string{"p2p", "dmarket"}}}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"csgoTrader/skinport"
+	"math"
+	"time"
+)
+
+// Days between the midpoints of the 7 and 30 day Steam averages
+const TREND_WINDOW_DAYS = 11.5
+
+// GetDailyTrend estimates the relative daily price movement of an item from its recent Steam averages
+func GetDailyTrend(itemName string) float64 {
+	prices := marketPrices[itemName]
+
+	if prices.Steam.Last7D == 0 || prices.Steam.Last30D == 0 {
+		return 0
+	}
+
+	return (prices.Steam.Last7D/prices.Steam.Last30D - 1) / TREND_WINDOW_DAYS
+}
+
+// ExpectedRevenue is the Buff price of an item projected to the end of its trade lock, minus the cost of holding it until then
+func ExpectedRevenue(itemName string, phase string, lock time.Duration) Money {
+	buffPrice := GetBuffPrice(itemName, phase)
+	days := lock.Hours() / 24
+
+	if days <= 0 {
+		return buffPrice
+	}
+
+	trend := 1 + GetDailyTrend(itemName)*days
+	holding := 1 - (config.DailyHoldingCost/100)*days
+
+	return NewMoney(buffPrice.Amount*math.Max(trend, 0)*math.Max(holding, 0), buffPrice.Currency)
+}
+
+func RequiredProfitPercentage(lock time.Duration) float64 {
+	if lock > 0 && config.MinimumLockedProfitPercentage != 0 {
+		return config.MinimumLockedProfitPercentage
+	}
+
+	return config.MinimumProfitPercentage
+}
+
+// IsProfitable checks the profit against the required percentage, raised by an extra margin for risky offers
+func IsProfitable(cost Money, itemName string, phase string, lock time.Duration, extraMargin float64) bool {
+	revenue := ExpectedRevenue(itemName, phase, lock)
+
+	if revenue.Amount <= 0 {
+		return false
+	}
+
+	profit, err := ProfitPercentage(cost, revenue)
+	if err != nil {
+		ErrorLogger.Println(err)
+		return false
+	}
+
+	return profit >= RequiredProfitPercentage(lock)+extraMargin
+}
+
+func GetDmarketLock(product *dmarket.Product) time.Duration {
+	return time.Duration(product.Extra.TradeLockDuration) * time.Second
+}
+
+func GetSkinportLock(product *skinport.Sale) time.Duration {
+	if product.Lock.IsZero() {
+		return 0
+	}
+
+	lock := time.Until(product.Lock)
+	if lock < 0 {
+		return 0
+	}
+
+	return lock
+}
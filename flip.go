@@ -0,0 +1,128 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+// The bought item can take a moment to show up in our inventory
+const FLIP_INVENTORY_ATTEMPTS = 5
+const FLIP_INVENTORY_DELAY = 3 * time.Second
+
+// GetInstantSellNet is what selling into the product's instant target pays out after Dmarket's instant sell fee
+func GetInstantSellNet(product *dmarket.Product) Money {
+	instantCents, _ := strconv.Atoi(product.InstantPrice.USD)
+	fee := product.Fees.Dmarket.InstantSell.Default
+
+	percentage, _ := strconv.ParseFloat(fee.Percentage, 64)
+	minFeeCents, _ := strconv.Atoi(fee.MinFee.USD)
+
+	feeCents := math.Max(math.Ceil(float64(instantCents)*percentage/100), float64(minFeeCents))
+	return MoneyFromCents(instantCents-int(feeCents), USD)
+}
+
+// IsInstantFlip reports whether an offer can be bought and sold straight into an existing Dmarket target at the required margin
+func IsInstantFlip(product *dmarket.Product) bool {
+	if product.InstantTargetID == "" || GetDmarketOfferType(product) != "dmarket" || GetDmarketLock(product) > 0 {
+		return false
+	}
+
+	net := GetInstantSellNet(product)
+	if net.Amount <= 0 {
+		return false
+	}
+
+	required := config.MinimumFlipProfitPercentage
+	if required == 0 {
+		required = config.MinimumProfitPercentage
+	}
+
+	profit, err := ProfitPercentage(GetDmarketPrice(product), net)
+	return err == nil && profit >= required
+}
+
+// findPurchasedItem finds the item a purchase put in our inventory and assigns it to the record.
+// The listing's item ID belonged to the seller, so ours is the unclaimed item of the same title, preferring the same ID.
+func findPurchasedItem(product *dmarket.Product) (dmarket.InventoryItem, error) {
+	for attempt := 0; attempt < FLIP_INVENTORY_ATTEMPTS; attempt++ {
+		if attempt > 0 {
+			time.Sleep(FLIP_INVENTORY_DELAY)
+		}
+
+		items, err := FetchDmarketInventory()
+		if err != nil {
+			return dmarket.InventoryItem{}, err
+		}
+
+		claimed := make(map[string]bool)
+		for _, record := range history.Filter(func(record *TradeRecord) bool {
+			return record.AssetID != ""
+		}) {
+			claimed[record.AssetID] = true
+		}
+
+		var found *dmarket.InventoryItem
+		for i := range items {
+			item := &items[i]
+			if item.Title != product.Title || item.InMarket || claimed[item.AssetID] {
+				continue
+			}
+
+			if found == nil || item.ItemID == product.ItemID || item.AssetID == product.ItemID {
+				found = item
+			}
+		}
+
+		if found != nil {
+			history.Update(MARKET_DMARKET, product.Extra.OfferID, func(record *TradeRecord) {
+				record.AssetID = found.AssetID
+			})
+			return *found, nil
+		}
+	}
+
+	return dmarket.InventoryItem{}, errors.New("Bought item not found in inventory")
+}
+
+// SellToInstantTarget completes the second leg of a flip, selling the bought item into the target
+func SellToInstantTarget(job *PurchaseJob) {
+	product := &job.Product
+	net := GetInstantSellNet(product)
+
+	item, err := findPurchasedItem(product)
+	if err == nil {
+		itemId := item.ItemID
+		if itemId == "" {
+			itemId = item.AssetID
+		}
+
+		var sellObj dmarket.TargetSellResponse
+		sellObj, err = dmarketClient.SellToTargets(PriorityContext(PRIORITY_PURCHASE), dmarket.TargetSell{
+			TargetID: product.InstantTargetID,
+			ItemID:   itemId,
+			Price:    dmarket.OfferPrice{Amount: product.InstantPrice.USD, Currency: "USD"},
+		})
+		if err == nil {
+			err = sellObj.Err()
+		}
+	}
+
+	if err != nil {
+		// The first leg already went through, so the item stays in our inventory to be sold another way
+		ReportError(errors.New("Bought " + product.Title + " to flip but selling into the target failed, item kept in inventory: " + err.Error()))
+		return
+	}
+
+	balances.Credit(net.Amount)
+	history.Update(MARKET_DMARKET, product.Extra.OfferID, func(record *TradeRecord) {
+		record.SalePrice = net
+		record.Status = TRADE_SOLD
+	})
+
+	if record, ok := history.Find(MARKET_DMARKET, product.Extra.OfferID); ok {
+		SendInstantFlip(product, record)
+	}
+}
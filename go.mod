@@ -6,6 +6,7 @@ require (
 	github.com/2captcha/2captcha-go v1.1.2
 	github.com/disgoorg/disgo v0.16.7
 	github.com/gorilla/websocket v1.5.0
+	golang.org/x/crypto v0.0.0-20220525230936-793ad666bf5e
 )
 
 require (
@@ -13,7 +14,6 @@ require (
 	github.com/disgoorg/log v1.2.0 // indirect
 	github.com/disgoorg/snowflake/v2 v2.0.1 // indirect
 	github.com/sasha-s/go-csync v0.0.0-20210812194225-61421b77c44b // indirect
-	golang.org/x/crypto v0.0.0-20220525230936-793ad666bf5e // indirect
 	golang.org/x/exp v0.0.0-20220325121720-054d8573a5d8 // indirect
 	golang.org/x/sys v0.1.0 // indirect
 )
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const HISTORY_FILE = "history.json"
+
+// Held by the bot or sync-history while they write the history, so they don't overwrite each other's changes
+const HISTORY_LOCK_FILE = "history.json.lock"
+
+const (
+	LOCK_OWNER_BOT          = "bot"
+	LOCK_OWNER_SYNC_HISTORY = "sync-history"
+)
+
+const (
+	MARKET_DMARKET  = "dmarket"
+	MARKET_SKINPORT = "skinport"
+)
+
+// Whether a trade was made by the bot or by hand, as found when importing Dmarket history
+const (
+	SOURCE_BOT    = "bot"
+	SOURCE_MANUAL = "manual"
+)
+
+const (
+	TRADE_PENDING   = "pending"
+	TRADE_CARTED    = "carted"
+	TRADE_COMPLETED = "completed"
+	TRADE_SOLD      = "sold"
+	TRADE_FAILED    = "failed"
+	TRADE_CANCELLED = "cancelled"
+	TRADE_TIMED_OUT = "timed out"
+	TRADE_UNKNOWN   = "unknown"
+)
+
+type TradeRecord struct {
+	ID        string `json:"id"`
+	Market    string `json:"market"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Category  string `json:"category"`
+	Price     Money  `json:"price"`
+	SalePrice Money  `json:"salePrice"`
+	OrderID   string `json:"orderId,omitempty"`
+	AssetID   string `json:"assetId,omitempty"`
+	ListPrice Money  `json:"listPrice"`
+	// Offer ID of our Dmarket listing of the item
+	ListingID string `json:"listingId,omitempty"`
+	Status    string `json:"status"`
+	Source    string `json:"source"`
+	SellerID  string `json:"sellerId,omitempty"`
+	// IDs of the Dmarket history entries the purchase and sale were imported from
+	HistoryID     string    `json:"historyId,omitempty"`
+	SaleHistoryID string    `json:"saleHistoryId,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// IsBot reports whether the bot made the trade. Records from before sources were tracked were all made by the bot.
+func (r *TradeRecord) IsBot() bool {
+	return r.Source != SOURCE_MANUAL
+}
+
+// IsSpent reports whether the record counts towards our spend, timed out trades count until settled manually
+func (r *TradeRecord) IsSpent() bool {
+	return r.Status != TRADE_FAILED && r.Status != TRADE_CANCELLED
+}
+
+// IsHeld reports whether we still hold the item, counting towards our exposure
+func (r *TradeRecord) IsHeld() bool {
+	return r.IsSpent() && r.Status != TRADE_SOLD && r.Status != TRADE_TIMED_OUT
+}
+
+// TradeHistory is the local record of every trade the bot has attempted, persisted as JSON
+type TradeHistory struct {
+	mutex   sync.Mutex
+	path    string
+	records []TradeRecord
+	// Incremented on every change, so caches built from the history know when to rebuild
+	version int
+	// Set during a batch, which saves once at the end
+	batching bool
+	unsaved  bool
+}
+
+var history = NewTradeHistory(HISTORY_FILE)
+
+func NewTradeHistory(path string) *TradeHistory {
+	return &TradeHistory{path: path}
+}
+
+func (h *TradeHistory) Load() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	file, err := ioutil.ReadFile(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(file, &h.records)
+}
+
+// save must be called with the mutex held
+func (h *TradeHistory) save() {
+	h.version++
+
+	if h.batching {
+		h.unsaved = true
+		return
+	}
+	h.unsaved = false
+
+	file, err := json.MarshalIndent(h.records, "", "  ")
+	if err != nil {
+		ErrorLogger.Println("Failed to encode trade history: " + err.Error())
+		return
+	}
+
+	if err = ioutil.WriteFile(h.path, file, 0666); err != nil {
+		ErrorLogger.Println("Failed to save trade history: " + err.Error())
+	}
+}
+
+// Batch runs change with saving deferred, then saves once if anything changed, so bulk imports don't rewrite the file per record
+func (h *TradeHistory) Batch(change func() error) error {
+	h.mutex.Lock()
+	h.batching = true
+	h.mutex.Unlock()
+
+	err := change()
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.batching = false
+	if h.unsaved {
+		h.save()
+	}
+
+	return err
+}
+
+// LockHistory takes the history lock for owner. The bot takes over a lock left by a previous run of the bot which crashed,
+// anything else is refused, as the history may be written by another process.
+func LockHistory(path string, owner string) error {
+	content := owner + " " + strconv.Itoa(os.Getpid())
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+	if os.IsExist(err) {
+		holder, readErr := ioutil.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+
+		if owner != LOCK_OWNER_BOT || !strings.HasPrefix(string(holder), LOCK_OWNER_BOT+" ") {
+			return errors.New("The trade history is in use by " + string(holder) + ", stop it first or delete " + path + " if it isn't running")
+		}
+
+		WarningLogger.Println("Taking over the history lock left by " + string(holder))
+		return ioutil.WriteFile(path, []byte(content), 0666)
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(content)
+	return err
+}
+
+func UnlockHistory(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		ErrorLogger.Println("Failed to remove the history lock: " + err.Error())
+	}
+}
+
+func (h *TradeHistory) Version() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.version
+}
+
+func (h *TradeHistory) Add(record TradeRecord) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.add(record)
+}
+
+func (h *TradeHistory) add(record TradeRecord) {
+	now := time.Now()
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = now
+	}
+	record.UpdatedAt = now
+
+	h.records = append(h.records, record)
+	h.save()
+}
+
+// Update applies change to the most recent record with the given market and ID
+func (h *TradeHistory) Update(market string, id string, change func(record *TradeRecord)) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for i := len(h.records) - 1; i >= 0; i-- {
+		if h.records[i].Market == market && h.records[i].ID == id {
+			change(&h.records[i])
+			h.records[i].UpdatedAt = time.Now()
+			h.save()
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *TradeHistory) SetStatus(market string, id string, status string) bool {
+	return h.Update(market, id, func(record *TradeRecord) {
+		record.Status = status
+	})
+}
+
+func (h *TradeHistory) Find(market string, id string) (TradeRecord, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for i := len(h.records) - 1; i >= 0; i-- {
+		if h.records[i].Market == market && h.records[i].ID == id {
+			return h.records[i], true
+		}
+	}
+
+	return TradeRecord{}, false
+}
+
+func (h *TradeHistory) Filter(match func(record *TradeRecord) bool) []TradeRecord {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.filter(match)
+}
+
+func (h *TradeHistory) filter(match func(record *TradeRecord) bool) []TradeRecord {
+	var records []TradeRecord
+	for i := range h.records {
+		if match(&h.records[i]) {
+			records = append(records, h.records[i])
+		}
+	}
+
+	return records
+}
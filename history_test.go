@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestHistoryBatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HISTORY_FILE)
+	h := NewTradeHistory(path)
+
+	failed := errors.New("page failed")
+	err := h.Batch(func() error {
+		for i := 0; i < 3; i++ {
+			h.Add(TradeRecord{ID: strconv.Itoa(i), Market: MARKET_DMARKET})
+			h.SetStatus(MARKET_DMARKET, strconv.Itoa(i), TRADE_COMPLETED)
+		}
+
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Error("history saved during the batch")
+		}
+
+		return failed
+	})
+	if err != failed {
+		t.Errorf("Batch() = %v, want the error of the change", err)
+	}
+
+	// Changes made before an error are still saved
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []TradeRecord
+	if err = json.Unmarshal(file, &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Errorf("saved %d records, want 3", len(records))
+	}
+
+	// Saving is back to per change after the batch
+	h.Add(TradeRecord{ID: "3", Market: MARKET_DMARKET})
+	if file, _ = ioutil.ReadFile(path); json.Unmarshal(file, &records) != nil || len(records) != 4 {
+		t.Errorf("saved %d records after the batch, want 4", len(records))
+	}
+}
+
+func TestLockHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		holder  string
+		owner   string
+		wantErr bool
+	}{
+		{"unlocked bot", "", LOCK_OWNER_BOT, false},
+		{"unlocked sync-history", "", LOCK_OWNER_SYNC_HISTORY, false},
+		{"bot over a crashed bot", LOCK_OWNER_BOT + " 123", LOCK_OWNER_BOT, false},
+		{"sync-history while the bot runs", LOCK_OWNER_BOT + " 123", LOCK_OWNER_SYNC_HISTORY, true},
+		{"bot while sync-history runs", LOCK_OWNER_SYNC_HISTORY + " 123", LOCK_OWNER_BOT, true},
+		{"sync-history twice", LOCK_OWNER_SYNC_HISTORY + " 123", LOCK_OWNER_SYNC_HISTORY, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), HISTORY_LOCK_FILE)
+			if test.holder != "" {
+				if err := ioutil.WriteFile(path, []byte(test.holder), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := LockHistory(path, test.owner)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("LockHistory(%q) = %v, want error %v", test.owner, err, test.wantErr)
+			}
+
+			holder, _ := ioutil.ReadFile(path)
+			want := test.owner + " " + strconv.Itoa(os.Getpid())
+			if test.wantErr {
+				want = test.holder
+			}
+			if string(holder) != want {
+				t.Errorf("lock held by %q, want %q", holder, want)
+			}
+
+			if !test.wantErr {
+				UnlockHistory(path)
+				if _, err = os.Stat(path); !os.IsNotExist(err) {
+					t.Error("lock still held after UnlockHistory")
+				}
+			}
+		})
+	}
+}
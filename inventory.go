@@ -0,0 +1,292 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	RELIST_COST   = "cost"
+	RELIST_MARKET = "market"
+)
+
+// Offers fetched when looking for the cheapest one, so our own listings can be skipped
+const LOWEST_OFFER_LIMIT = 10
+
+// FetchDmarketInventory returns every item in our Dmarket inventory, including ones already listed
+func FetchDmarketInventory() ([]dmarket.InventoryItem, error) {
+	var items []dmarket.InventoryItem
+	cursor := ""
+
+	for {
+		inventoryObj, err := dmarketClient.Inventory(PriorityContext(PRIORITY_ACCOUNT), cursor)
+		if err != nil {
+			return items, err
+		}
+
+		items = append(items, inventoryObj.Items...)
+
+		if inventoryObj.Cursor == "" || len(inventoryObj.Items) == 0 {
+			return items, nil
+		}
+		cursor = inventoryObj.Cursor
+	}
+}
+
+func CreateDmarketOffer(assetId string, price Money) error {
+	resultObj, err := dmarketClient.CreateOffers(PriorityContext(PRIORITY_ACCOUNT), dmarket.UserOffer{
+		AssetID: assetId,
+		Price:   dmarket.Price{Currency: string(price.Currency), Amount: price.Amount},
+	})
+	if err != nil {
+		return err
+	}
+
+	return resultObj.Err()
+}
+
+func EditDmarketOffer(offerId string, assetId string, price Money) error {
+	resultObj, err := dmarketClient.EditOffers(PriorityContext(PRIORITY_ACCOUNT), dmarket.UserOffer{
+		OfferID: offerId,
+		AssetID: assetId,
+		Price:   dmarket.Price{Currency: string(price.Currency), Amount: price.Amount},
+	})
+	if err != nil {
+		return err
+	}
+
+	return resultObj.Err()
+}
+
+// GetLowestDmarketOffer returns the cheapest listing of an item on Dmarket, skipping our own offers and items
+func GetLowestDmarketOffer(title string, own map[string]bool) (Money, error) {
+	query := dmarket.NewItemsQuery().WithTitle(title).WithOrder("price", "asc").WithLimit(LOWEST_OFFER_LIMIT)
+
+	productsObj, err := FetchDmarketProducts(query, PRIORITY_ACCOUNT)
+	if err != nil {
+		return Money{}, err
+	}
+
+	for _, product := range productsObj.Objects {
+		if own[product.Extra.OfferID] || own[product.ItemID] {
+			continue
+		}
+
+		if product.Title == title {
+			return GetDmarketPrice(&product), nil
+		}
+	}
+
+	return Money{}, errors.New("No Dmarket offers for " + title)
+}
+
+// GetRelistPrice prices a held item by the configured mode, never below what we paid for it.
+// In market mode the item is listed relistUndercut cents under the cheapest competing offer, so it sells first.
+func GetRelistPrice(record *TradeRecord, own map[string]bool) (Money, error) {
+	cost := NewMoney(math.Ceil(toUsd(record.Price)*100)/100, USD)
+
+	cents := int(math.Ceil(cost.Amount * (1 + config.RelistMarkup/100) * 100))
+	if config.RelistMode == RELIST_MARKET {
+		lowest, err := GetLowestDmarketOffer(record.Title, own)
+		if err != nil {
+			return Money{}, err
+		}
+
+		cents = int(math.Round(toUsd(lowest)*100)) - config.RelistUndercut
+	}
+
+	if cents < cost.Cents() {
+		return cost, nil
+	}
+
+	return MoneyFromCents(cents, USD), nil
+}
+
+func RunInventory(delayMinutes int) {
+	if delayMinutes <= 0 {
+		return
+	}
+
+	ticker := time.Tick(time.Duration(delayMinutes) * time.Minute)
+	for range ticker {
+		SyncInventory()
+	}
+}
+
+// SyncInventory matches inventory items to our purchases, marks sold listings and lists or reprices the rest
+func SyncInventory() {
+	items, err := FetchDmarketInventory()
+	if err != nil {
+		ErrorLogger.Println("Failed to fetch inventory: " + err.Error())
+		return
+	}
+
+	inventory := make(map[string]dmarket.InventoryItem)
+	own := make(map[string]bool)
+	for _, item := range items {
+		inventory[item.AssetID] = item
+		own[item.AssetID] = true
+		own[item.ItemID] = true
+		if item.Offer.OfferID != "" {
+			own[item.Offer.OfferID] = true
+		}
+	}
+
+	// Listed items which have left the inventory have either sold or been withdrawn
+	var gone []TradeRecord
+	for _, record := range history.Filter(func(record *TradeRecord) bool {
+		return record.Market == MARKET_DMARKET && record.IsBot() && record.Status == TRADE_COMPLETED && record.AssetID != ""
+	}) {
+		if _, ok := inventory[record.AssetID]; !ok && record.ListPrice.Amount > 0 {
+			gone = append(gone, record)
+		}
+	}
+	if len(gone) > 0 {
+		recordSales(gone)
+	}
+
+	matchInventory(items)
+
+	if config.RelistMode == "" {
+		return
+	}
+
+	// Trades made by hand are left for the user to sell
+	for _, record := range history.Filter(func(record *TradeRecord) bool {
+		return record.Market == MARKET_DMARKET && record.IsBot() && record.Status == TRADE_COMPLETED && record.AssetID != ""
+	}) {
+		item, ok := inventory[record.AssetID]
+		if !ok || !item.Tradable {
+			continue
+		}
+
+		relist(&record, &item, own)
+	}
+}
+
+// recordSales settles listed items which left the inventory from our recent sales,
+// matched by listing ID or else by title. Items without a sale were withdrawn and stay held.
+func recordSales(gone []TradeRecord) {
+	historyObj, err := FetchDmarketHistory("sell", 0, HISTORY_PAGE_LIMIT, PRIORITY_ACCOUNT)
+	if err != nil {
+		ErrorLogger.Println("Failed to fetch sales: " + err.Error())
+		return
+	}
+
+	claimed := make(map[string]bool)
+	for _, record := range history.Filter(func(record *TradeRecord) bool {
+		return record.SaleHistoryID != ""
+	}) {
+		claimed[record.SaleHistoryID] = true
+	}
+
+	for _, record := range gone {
+		var sale *dmarket.HistoryEntry
+		for i := range historyObj.Objects {
+			entry := &historyObj.Objects[i]
+			if claimed[entry.ID] || !isSuccessfulHistoryEntry(entry) {
+				continue
+			}
+
+			if record.ListingID != "" && entry.OfferID == record.ListingID {
+				sale = entry
+				break
+			}
+			if sale == nil && entry.Subject == record.Title {
+				sale = entry
+			}
+		}
+
+		if sale == nil {
+			InfoLogger.Println(record.Title + " left the inventory without a sale, assuming it was withdrawn")
+			history.Update(MARKET_DMARKET, record.ID, func(withdrawn *TradeRecord) {
+				withdrawn.ListPrice = Money{}
+				withdrawn.ListingID = ""
+			})
+			continue
+		}
+
+		claimed[sale.ID] = true
+		record.SalePrice = getHistoryAmount(sale)
+		record.Status = TRADE_SOLD
+		history.Update(MARKET_DMARKET, record.ID, func(sold *TradeRecord) {
+			sold.Status = TRADE_SOLD
+			sold.SalePrice = record.SalePrice
+			sold.SaleHistoryID = sale.ID
+		})
+		SendListingSold(record)
+	}
+}
+
+// matchInventory assigns unmatched inventory items to the oldest unmatched purchase with the same title
+func matchInventory(items []dmarket.InventoryItem) {
+	matched := make(map[string]bool)
+	for _, record := range history.Filter(func(record *TradeRecord) bool {
+		return record.AssetID != ""
+	}) {
+		matched[record.AssetID] = true
+	}
+
+	unmatched := history.Filter(func(record *TradeRecord) bool {
+		return record.Market == MARKET_DMARKET && record.IsBot() && record.Status == TRADE_COMPLETED && record.AssetID == ""
+	})
+	sort.Slice(unmatched, func(i, j int) bool {
+		return unmatched[i].CreatedAt.Before(unmatched[j].CreatedAt)
+	})
+
+	for _, item := range items {
+		if matched[item.AssetID] {
+			continue
+		}
+
+		for i := range unmatched {
+			if unmatched[i].Title != item.Title || unmatched[i].AssetID != "" {
+				continue
+			}
+
+			unmatched[i].AssetID = item.AssetID
+			history.Update(MARKET_DMARKET, unmatched[i].ID, func(record *TradeRecord) {
+				record.AssetID = item.AssetID
+			})
+			InfoLogger.Println("Matched inventory item", item.Title, "to purchase", unmatched[i].ID)
+			break
+		}
+	}
+}
+
+func relist(record *TradeRecord, item *dmarket.InventoryItem, own map[string]bool) {
+	if item.Offer.OfferID != "" && item.Offer.OfferID != record.ListingID {
+		history.Update(MARKET_DMARKET, record.ID, func(listed *TradeRecord) {
+			listed.ListingID = item.Offer.OfferID
+		})
+	}
+
+	price, err := GetRelistPrice(record, own)
+	if err != nil {
+		ErrorLogger.Println("Failed to price " + record.Title + ": " + err.Error())
+		return
+	}
+
+	if item.Offer.OfferID == "" {
+		InfoLogger.Printf("Listing %s at %s\n", record.Title, price)
+		if err = CreateDmarketOffer(item.AssetID, price); err != nil {
+			ReportError(errors.New("Failed to list " + record.Title + ": " + err.Error()))
+			return
+		}
+	} else if NewMoney(item.Offer.Price.Amount, USD).Cents() != price.Cents() {
+		InfoLogger.Printf("Repricing %s from $%.2f to %s\n", record.Title, item.Offer.Price.Amount, price)
+		if err = EditDmarketOffer(item.Offer.OfferID, item.AssetID, price); err != nil {
+			ErrorLogger.Println("Failed to reprice " + record.Title + ": " + err.Error())
+			return
+		}
+	} else {
+		return
+	}
+
+	history.Update(MARKET_DMARKET, record.ID, func(listed *TradeRecord) {
+		listed.ListPrice = price
+	})
+}
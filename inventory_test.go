@@ -0,0 +1,73 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// serveOffers serves market items at the given prices in cents, cheapest first, each with offer ID offer-<index>
+func serveOffers(t *testing.T, title string, cents ...int) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var productsObj dmarket.ProductsResponse
+		for i, price := range cents {
+			var product dmarket.Product
+			product.Title = title
+			product.Extra.OfferID = "offer-" + strconv.Itoa(i)
+			product.Price.USD = strconv.Itoa(price)
+			productsObj.Objects = append(productsObj.Objects, product)
+		}
+
+		json.NewEncoder(w).Encode(productsObj)
+	}))
+	t.Cleanup(server.Close)
+
+	client := dmarketClient
+	dmarketClient = dmarket.NewClient("", "", server.URL, nil)
+	t.Cleanup(func() { dmarketClient = client })
+}
+
+func TestGetRelistPrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		markup   float64
+		undercut int
+		cost     int
+		offers   []int
+		own      map[string]bool
+		want     int
+	}{
+		{"cost markup", RELIST_COST, 10, 1, 1000, nil, nil, 1100},
+		{"cost markup rounds up", RELIST_COST, 10, 1, 999, nil, nil, 1099},
+		{"market undercuts the cheapest offer", RELIST_MARKET, 10, 1, 1000, []int{1500, 1600}, nil, 1499},
+		{"market ignores the markup", RELIST_MARKET, 50, 5, 1000, []int{1200}, nil, 1195},
+		{"market skips our own offer", RELIST_MARKET, 10, 1, 1000, []int{1300, 1500}, map[string]bool{"offer-0": true}, 1499},
+		{"market never below cost", RELIST_MARKET, 10, 1, 1000, []int{900}, nil, 1000},
+		{"market at cost", RELIST_MARKET, 10, 1, 1000, []int{1001}, nil, 1000},
+	}
+
+	previous := config
+	t.Cleanup(func() { config = previous })
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.RelistMode = test.mode
+			config.RelistMarkup = test.markup
+			config.RelistUndercut = test.undercut
+			serveOffers(t, "AK-47 | Redline (Field-Tested)", test.offers...)
+
+			record := TradeRecord{Title: "AK-47 | Redline (Field-Tested)", Price: MoneyFromCents(test.cost, USD)}
+			price, err := GetRelistPrice(&record, test.own)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if price.Cents() != test.want {
+				t.Errorf("GetRelistPrice() = %d cents, want %d", price.Cents(), test.want)
+			}
+		})
+	}
+}
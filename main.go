@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
@@ -11,18 +12,63 @@ import (
 var config Configuration
 
 type Configuration struct {
-	MonitorDelay            int     `json:"monitorDelay"`
-	Webhook                 string  `json:"webhook"`
-	SkinportUsername        string  `json:"skinportUsername"`
-	SkinportPassword        string  `json:"skinportPassword"`
-	TwoCaptchaKey           string  `json:"twoCaptchaKey"`
-	BotToken                string  `json:"botToken"`
-	DmarketPublicKey        string  `json:"dmarketPublicKey"`
-	DmarketPrivateKey       string  `json:"dmarketPrivateKey"`
-	MinimumProfitPercentage float64 `json:"minimumProfitPercentage"`
-	MinimumPrice            float64 `json:"minimumPrice"`
-	MaximumPrice            float64 `json:"maximumPrice"`
-	InputChannel            string  `json:"inputChannel"`
+	MonitorDelay                  int                `json:"monitorDelay"`
+	Webhook                       string             `json:"webhook"`
+	SkinportUsername              string             `json:"skinportUsername"`
+	SkinportPassword              string             `json:"skinportPassword"`
+	TwoCaptchaKey                 string             `json:"twoCaptchaKey"`
+	BotToken                      string             `json:"botToken"`
+	DmarketPublicKey              string             `json:"dmarketPublicKey"`
+	DmarketPrivateKey             string             `json:"dmarketPrivateKey"`
+	MinimumProfitPercentage       float64            `json:"minimumProfitPercentage"`
+	MinimumPrice                  float64            `json:"minimumPrice"`
+	MaximumPrice                  float64            `json:"maximumPrice"`
+	InputChannel                  string             `json:"inputChannel"`
+	DailyHoldingCost              float64            `json:"dailyHoldingCost"`
+	MinimumLockedProfitPercentage float64            `json:"minimumLockedProfitPercentage"`
+	RatesFile                     string             `json:"ratesFile"`
+	CurrencySpread                float64            `json:"currencySpread"`
+	RatesUpdateDelay              int                `json:"ratesUpdateDelay"`
+	DailySpendLimit               float64            `json:"dailySpendLimit"`
+	WeeklySpendLimit              float64            `json:"weeklySpendLimit"`
+	MaxItemHoldings               int                `json:"maxItemHoldings"`
+	MaxCategoryExposure           float64            `json:"maxCategoryExposure"`
+	HoldingDays                   int                `json:"holdingDays"`
+	MaxPurchaseShare              float64            `json:"maxPurchaseShare"`
+	Bankroll                      float64            `json:"bankroll"`
+	StatusDelay                   int                `json:"statusDelay"`
+	BalanceReconcileDelay         int                `json:"balanceReconcileDelay"`
+	PurchaseWorkers               int                `json:"purchaseWorkers"`
+	SeenTtl                       int                `json:"seenTtl"`
+	EvaluateInitialPage           bool               `json:"evaluateInitialPage"`
+	SweepDelay                    int                `json:"sweepDelay"`
+	SweepPageDelay                int                `json:"sweepPageDelay"`
+	DmarketQueries                []DmarketQueryRule `json:"dmarketQueries"`
+	DmarketRequestInterval        int                `json:"dmarketRequestInterval"`
+	Targets                       []TargetRule       `json:"targets"`
+	TargetDelay                   int                `json:"targetDelay"`
+	TargetRepriceThreshold        float64            `json:"targetRepriceThreshold"`
+	TargetFeePercentage           float64            `json:"targetFeePercentage"`
+	InstantFlip                   bool               `json:"instantFlip"`
+	MinimumFlipProfitPercentage   float64            `json:"minimumFlipProfitPercentage"`
+	InventoryDelay                int                `json:"inventoryDelay"`
+	RelistMode                    string             `json:"relistMode"`
+	RelistMarkup                  float64            `json:"relistMarkup"`
+	RelistUndercut                int                `json:"relistUndercut"`
+	P2PTrackerDelay               int                `json:"p2pTrackerDelay"`
+	P2PReminderDelay              int                `json:"p2pReminderDelay"`
+	P2PTradeTimeout               int                `json:"p2pTradeTimeout"`
+	SellerBlocklist               []string           `json:"sellerBlocklist"`
+	MaxSellerRisk                 float64            `json:"maxSellerRisk"`
+	SellerRiskMargin              float64            `json:"sellerRiskMargin"`
+	MaxClockSkew                  int                `json:"maxClockSkew"`
+	SkinportOutageAlert           int                `json:"skinportOutageAlert"`
+	MaxCartSize                   int                `json:"maxCartSize"`
+	CartStaleMinutes              int                `json:"cartStaleMinutes"`
+	CartSyncDelay                 int                `json:"cartSyncDelay"`
+	SessionKey                    string             `json:"sessionKey"`
+	SessionProbeDelay             int                `json:"sessionProbeDelay"`
+	CookieDropFolder              string             `json:"cookieDropFolder"`
 }
 
 var (
@@ -31,7 +77,8 @@ var (
 	ErrorLogger   *log.Logger
 )
 
-func init() {
+// setup opens the logs, loads the config and restores the state saved by previous runs
+func setup() {
 	file, err := os.OpenFile("logs.txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
 	if err != nil {
 		log.Fatal(err)
@@ -51,18 +98,96 @@ func init() {
 	jsonParser.Decode(&config)
 
 	CreateWebhookClient(config.Webhook)
+
+	if err = history.Load(); err != nil {
+		log.Fatal(err)
+	}
+
+	scheduler = NewDmarketScheduler(config.DmarketRequestInterval)
+	dmarketClient = NewDmarketClient(config.DmarketPublicKey, config.DmarketPrivateKey)
+
+	seen = NewSeenIndex(SEEN_FILE, config.SeenTtl)
+	if err = seen.Load(); err != nil {
+		ErrorLogger.Println("Failed to load seen listings: " + err.Error())
+	}
+
+	if err = skinportListings.Load(); err != nil {
+		ErrorLogger.Println("Failed to load liquidity stats: " + err.Error())
+	}
+
+	currencies.SetSpread(config.CurrencySpread)
+	if config.RatesFile != "" {
+		if err = currencies.LoadRatesFile(config.RatesFile); err != nil {
+			ErrorLogger.Println("Failed to load rates file: " + err.Error())
+		}
+	}
 }
 
 func main() {
+	setup()
+
+	cookiesPath := flag.String("cookies", "", "Log in to Skinport with a cookies.txt, JSON or HAR cookie export")
+	flag.Parse()
+
+	if flag.Arg(0) == "sync-history" {
+		if err := LockHistory(HISTORY_LOCK_FILE, LOCK_OWNER_SYNC_HISTORY); err != nil {
+			log.Fatal(err)
+		}
+
+		err := SyncDmarketHistory()
+		UnlockHistory(HISTORY_LOCK_FILE)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := LockHistory(HISTORY_LOCK_FILE, LOCK_OWNER_BOT); err != nil {
+		log.Fatal(err)
+	}
+
 	UpdateAvailableBalance()
-	login()
+
+	go RunCookieDropFolder(config.CookieDropFolder)
+	if *cookiesPath != "" {
+		if err := ImportSkinportCookies(*cookiesPath); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		RestoreSkinportSession()
+	}
 	fetchPrices()
 
-	go RunDmarket(config.MonitorDelay, "p2p")
-	go RunDmarket(config.MonitorDelay, "dmarket")
+	if err := currencies.UpdateFromSkinport(); err != nil {
+		ErrorLogger.Println("Failed to fetch exchange rates: " + err.Error())
+	}
+
+	dispatcher = NewPurchaseDispatcher(config.PurchaseWorkers)
+	ResumeReconciliation()
+	go RunTargets(config.TargetDelay)
+	go RunInventory(config.InventoryDelay)
+	go RunP2PTracker(config.P2PTrackerDelay)
+
+	for _, rule := range GetDmarketQueryRules() {
+		go RunDmarket(config.MonitorDelay, rule.Query())
+		go RunDmarketSweep(config.SweepDelay, config.SweepPageDelay, rule.Query())
+	}
 	go RunSkinport()
+	go RunSkinportCart(config.CartSyncDelay)
+	go RunSessionProbe(config.SessionProbeDelay)
+	go RunCurrencyUpdates(config.RatesUpdateDelay)
+	go RunStatus(config.StatusDelay)
+	go RunBalanceReconciler(config.BalanceReconcileDelay)
+	go RunSeenSaver()
+	go RunSkinportListings()
+
+	SendStatus(GetStatus())
 
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, os.Kill)
 	<-sc
+
+	seen.Save()
+	skinportListings.Save()
+	UnlockHistory(HISTORY_LOCK_FILE)
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/disgoorg/disgo/discord"
+	"github.com/disgoorg/disgo/rest"
+	"github.com/disgoorg/disgo/webhook"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeWebhook counts the notifications sent instead of posting them to Discord
+type fakeWebhook struct {
+	webhook.Client
+	embeds   int
+	messages int
+}
+
+func (w *fakeWebhook) CreateEmbeds(embeds []discord.Embed, opts ...rest.RequestOpt) (*discord.Message, error) {
+	w.embeds++
+	return &discord.Message{}, nil
+}
+
+func (w *fakeWebhook) CreateMessage(messageCreate discord.WebhookMessageCreate, opts ...rest.RequestOpt) (*discord.Message, error) {
+	w.messages++
+	return &discord.Message{}, nil
+}
+
+// useFakeWebhook replaces the Discord webhook for the duration of a test
+func useFakeWebhook(t *testing.T) *fakeWebhook {
+	fake := &fakeWebhook{}
+	client := WebhookClient
+	WebhookClient = fake
+	t.Cleanup(func() { WebhookClient = client })
+
+	return fake
+}
+
+// useTempHistory replaces the trade history with an empty one saved in a temporary directory
+func useTempHistory(t *testing.T) {
+	previous := history
+	history = NewTradeHistory(filepath.Join(t.TempDir(), HISTORY_FILE))
+	t.Cleanup(func() { history = previous })
+}
+
+func TestMain(m *testing.M) {
+	InfoLogger = log.New(ioutil.Discard, "", 0)
+	WarningLogger = log.New(ioutil.Discard, "", 0)
+	ErrorLogger = log.New(ioutil.Discard, "", 0)
+
+	os.Exit(m.Run())
+}
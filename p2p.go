@@ -0,0 +1,118 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"errors"
+	"strings"
+	"time"
+)
+
+// P2PTracker follows started P2P purchases until the seller delivers, cancels or we give up on them
+type P2PTracker struct {
+	reminded map[string]time.Time
+}
+
+func NewP2PTracker() *P2PTracker {
+	return &P2PTracker{reminded: make(map[string]time.Time)}
+}
+
+// FetchP2PTrade finds the trade of a purchase, by its order ID when known as an offer can be bought more than once
+func FetchP2PTrade(record *TradeRecord) (dmarket.P2PTrade, error) {
+	tradesObj, err := dmarketClient.P2PTrades(PriorityContext(PRIORITY_ACCOUNT), record.ID)
+	if err != nil {
+		return dmarket.P2PTrade{}, err
+	}
+
+	for _, trade := range tradesObj.Trades {
+		if record.OrderID != "" {
+			if trade.OrderID == record.OrderID {
+				return trade, nil
+			}
+		} else if trade.OfferID == record.ID {
+			return trade, nil
+		}
+	}
+
+	return dmarket.P2PTrade{}, errors.New("No P2P trade found for offer " + record.ID)
+}
+
+func RunP2PTracker(delaySeconds int) {
+	if delaySeconds <= 0 {
+		return
+	}
+
+	tracker := NewP2PTracker()
+
+	ticker := time.Tick(time.Duration(delaySeconds) * time.Second)
+	for range ticker {
+		tracker.Check()
+	}
+}
+
+// Check polls every pending P2P purchase once. Trades which can't be fetched still time out, so they don't hold their budget forever.
+func (t *P2PTracker) Check() {
+	pending := history.Filter(func(record *TradeRecord) bool {
+		return record.Market == MARKET_DMARKET && record.Type == "p2p" && record.Status == TRADE_PENDING
+	})
+
+	for _, record := range pending {
+		status := ""
+		if trade, err := FetchP2PTrade(&record); err != nil {
+			ErrorLogger.Println("Failed to fetch P2P trade: " + err.Error())
+		} else {
+			status = strings.ToLower(trade.Status)
+		}
+
+		switch status {
+		case "completed", "success", "successful":
+			t.settle(record, TRADE_COMPLETED)
+		case "canceled", "cancelled", "failed", "expired", "rejected":
+			t.settle(record, TRADE_CANCELLED)
+		default:
+			t.wait(record)
+		}
+	}
+}
+
+// wait times out a purchase the seller hasn't delivered in time, reminding us of it meanwhile
+func (t *P2PTracker) wait(record TradeRecord) {
+	age := time.Since(record.CreatedAt)
+
+	if config.P2PTradeTimeout > 0 && age > time.Duration(config.P2PTradeTimeout)*time.Minute {
+		t.settle(record, TRADE_TIMED_OUT)
+	} else if config.P2PReminderDelay > 0 && time.Since(t.reminded[record.ID]) > time.Duration(config.P2PReminderDelay)*time.Minute {
+		t.reminded[record.ID] = time.Now()
+		SendP2PUpdate(record, "Waiting for the seller to send the trade ("+age.Round(time.Minute).String()+" since purchase)")
+	}
+}
+
+func (t *P2PTracker) settle(record TradeRecord, status string) {
+	history.SetStatus(MARKET_DMARKET, record.ID, status)
+	delete(t.reminded, record.ID)
+
+	switch status {
+	case TRADE_COMPLETED:
+		SendP2PUpdate(record, "Trade delivered")
+	case TRADE_CANCELLED:
+		SendP2PUpdate(record, "Trade cancelled, funds should be returned to the Dmarket balance")
+	case TRADE_TIMED_OUT:
+		SendP2PUpdate(record, "Trade not delivered in time, check it manually on Dmarket")
+	}
+
+	// The purchase price was committed when the trade started, refetch the balance in case it was refunded
+	if status != TRADE_COMPLETED {
+		UpdateAvailableBalance()
+	}
+}
+
+// GetP2PStats counts P2P purchases by their final state
+func GetP2PStats() map[string]int {
+	stats := make(map[string]int)
+	for _, record := range history.Filter(func(record *TradeRecord) bool {
+		return record.Market == MARKET_DMARKET && record.Type == "p2p"
+	}) {
+		stats[record.Status]++
+	}
+
+	return stats
+}
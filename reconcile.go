@@ -0,0 +1,116 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"errors"
+	"strings"
+	"time"
+)
+
+const RECONCILE_ATTEMPTS = 5
+const RECONCILE_DELAY = 30 * time.Second
+
+// History entries are searched back to this long before the purchase was attempted, allowing for clock differences
+const RECONCILE_LOOKBACK = 5 * time.Minute
+
+func FetchDmarketHistory(activities string, offset int, limit int, priority int) (dmarket.HistoryResponse, error) {
+	return dmarketClient.History(PriorityContext(priority), activities, offset, limit)
+}
+
+// FindDmarketPurchase looks for the offer in our purchase history, paging back to when the purchase was attempted
+func FindDmarketPurchase(offerId string, since time.Time) (dmarket.HistoryEntry, bool, error) {
+	since = since.Add(-RECONCILE_LOOKBACK)
+
+	for offset := 0; ; offset += HISTORY_PAGE_LIMIT {
+		historyObj, err := FetchDmarketHistory("purchase", offset, HISTORY_PAGE_LIMIT, PRIORITY_PURCHASE)
+		if err != nil {
+			return dmarket.HistoryEntry{}, false, err
+		}
+
+		for _, entry := range historyObj.Objects {
+			if entry.OfferID == offerId {
+				return entry, true, nil
+			}
+		}
+
+		// The history is newest first, so stop once a page reaches back past the purchase
+		objects := historyObj.Objects
+		if len(objects) < HISTORY_PAGE_LIMIT || time.Unix(objects[len(objects)-1].CreatedAt, 0).Before(since) {
+			return dmarket.HistoryEntry{}, false, nil
+		}
+	}
+}
+
+// ReconcilePurchase settles a purchase whose outcome is unknown from Dmarket's purchase history.
+// The record stays unknown and its balance reserved meanwhile, so the offer can't be bought twice.
+func ReconcilePurchase(record TradeRecord, reason string) {
+	// Callers may hold the record from before it was added to the history, without the time the purchase was attempted
+	if stored, ok := history.Find(MARKET_DMARKET, record.ID); ok {
+		record = stored
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+
+	history.SetStatus(MARKET_DMARKET, record.ID, TRADE_UNKNOWN)
+	ReportError(errors.New("Outcome of purchasing " + record.Title + " is unknown (" + reason + "), checking purchase history"))
+
+	for attempt := 1; attempt <= RECONCILE_ATTEMPTS; attempt++ {
+		time.Sleep(RECONCILE_DELAY * time.Duration(attempt))
+
+		entry, found, err := FindDmarketPurchase(record.ID, record.CreatedAt)
+		if err != nil {
+			ErrorLogger.Println("Failed to fetch purchase history: " + err.Error())
+			continue
+		}
+
+		if !found {
+			continue
+		}
+
+		switch strings.ToLower(entry.Status) {
+		case "success", "successful", "completed":
+			settlePurchase(record, TRADE_COMPLETED, entry.OrderID)
+			return
+		case "pending", "in_progress", "started":
+			// P2P purchases stay pending until the seller sends the trade
+			settlePurchase(record, TRADE_PENDING, entry.OrderID)
+			return
+		case "failed", "canceled", "cancelled", "reverted":
+			settlePurchase(record, TRADE_FAILED, "")
+			return
+		}
+	}
+
+	// A purchase missing from a slow history may still have gone through, so it stays unknown with its balance reserved
+	ReportError(errors.New("Purchase of " + record.Title + " (" + record.ID + ") not found in the purchase history, check it manually on Dmarket"))
+}
+
+func settlePurchase(record TradeRecord, status string, orderId string) {
+	if status == TRADE_FAILED {
+		balances.Release(record.ID)
+	} else {
+		balances.Commit(record.ID)
+	}
+
+	history.Update(MARKET_DMARKET, record.ID, func(settled *TradeRecord) {
+		settled.Status = status
+		if orderId != "" {
+			settled.OrderID = orderId
+		}
+	})
+
+	record.Status = status
+	SendReconciledPurchase(record)
+}
+
+// ResumeReconciliation picks up purchases left unknown when the bot last stopped, reserving their balance again.
+// If one did go through, it is deducted twice until the next balance reconcile, erring on the safe side.
+func ResumeReconciliation() {
+	for _, record := range history.Filter(func(record *TradeRecord) bool {
+		return record.Market == MARKET_DMARKET && record.Status == TRADE_UNKNOWN
+	}) {
+		balances.Set(record.ID, toUsd(record.Price))
+		go ReconcilePurchase(record, "bot restarted before the purchase settled")
+	}
+}
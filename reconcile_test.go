@@ -0,0 +1,75 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serveHistory serves a purchase history of total entries, one a minute going back from now, counting the pages fetched
+func serveHistory(t *testing.T, total int, now time.Time, pages *int) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*pages++
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		var historyObj dmarket.HistoryResponse
+		for i := offset; i < offset+limit && i < total; i++ {
+			historyObj.Objects = append(historyObj.Objects, dmarket.HistoryEntry{
+				OfferID:   "offer-" + strconv.Itoa(i),
+				Status:    "success",
+				CreatedAt: now.Add(-time.Duration(i) * time.Minute).Unix(),
+			})
+		}
+		historyObj.Total = total
+
+		json.NewEncoder(w).Encode(historyObj)
+	}))
+	t.Cleanup(server.Close)
+
+	client := dmarketClient
+	dmarketClient = dmarket.NewClient("", "", server.URL, nil)
+	t.Cleanup(func() { dmarketClient = client })
+}
+
+func TestFindDmarketPurchase(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		total   int
+		offerId string
+		since   time.Time
+		found   bool
+		pages   int
+	}{
+		{"on the first page", 1000, "offer-3", now, true, 1},
+		{"on a later page", 1000, "offer-250", now.Add(-250 * time.Minute), true, 3},
+		{"missing recent purchase", 1000, "missing", now, false, 1},
+		{"missing older purchase", 1000, "missing", now.Add(-150 * time.Minute), false, 2},
+		{"missing from a short history", 50, "missing", time.Time{}, false, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pages := 0
+			serveHistory(t, test.total, now, &pages)
+
+			entry, found, err := FindDmarketPurchase(test.offerId, test.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found != test.found || (found && entry.OfferID != test.offerId) {
+				t.Errorf("FindDmarketPurchase(%q) = %q, %v, want found %v", test.offerId, entry.OfferID, found, test.found)
+			}
+			if pages != test.pages {
+				t.Errorf("FindDmarketPurchase(%q) fetched %d pages, want %d", test.offerId, pages, test.pages)
+			}
+		})
+	}
+}
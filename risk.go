@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const DEFAULT_HOLDING_DAYS = 14
+
+// Budget is what is left of a spend limit, Limited is false when no limit is set
+type Budget struct {
+	Remaining float64
+	Limited   bool
+}
+
+type RiskManager struct {
+	history *TradeHistory
+}
+
+var risk = &RiskManager{history: history}
+
+func toUsd(m Money) float64 {
+	usd, err := currencies.ConvertAtMid(m, USD)
+	if err != nil {
+		ErrorLogger.Println(err)
+		return m.Amount
+	}
+
+	return usd.Amount
+}
+
+// GetBankroll returns the configured bankroll, or the available Dmarket balance if none is set
+func GetBankroll() Money {
+	if config.Bankroll > 0 {
+		return NewMoney(config.Bankroll, USD)
+	}
+
+	return NewMoney(balances.Available(), USD)
+}
+
+// spentSince must be called with the history mutex held
+func (r *RiskManager) spentSince(since time.Time) float64 {
+	spent := 0.0
+	for _, record := range r.history.filter(func(record *TradeRecord) bool {
+		return record.IsSpent() && record.CreatedAt.After(since)
+	}) {
+		spent += toUsd(record.Price)
+	}
+
+	return spent
+}
+
+// holdingSince is the oldest purchase still counted as held. Purchases nothing marks sold, e.g. Skinport
+// ones sold by hand, would otherwise count towards the holding limits forever.
+func holdingSince(now time.Time) time.Time {
+	days := config.HoldingDays
+	if days <= 0 {
+		days = DEFAULT_HOLDING_DAYS
+	}
+
+	return now.Add(-time.Duration(days) * 24 * time.Hour)
+}
+
+// check must be called with the history mutex held
+func (r *RiskManager) check(record *TradeRecord, bankroll Money) error {
+	price := toUsd(record.Price)
+	now := time.Now()
+
+	// Never buy the same listing twice, including while an earlier attempt is still being settled
+	if record.ID != "" && len(r.history.filter(func(existing *TradeRecord) bool {
+		return existing.Market == record.Market && existing.ID == record.ID && existing.IsSpent()
+	})) > 0 {
+		return errors.New(record.Title + " (" + record.ID + ") has already been purchased")
+	}
+
+	if config.MaxPurchaseShare > 0 && bankroll.Amount > 0 && price > toUsd(bankroll)*config.MaxPurchaseShare/100 {
+		return fmt.Errorf("%s costs more than %.0f%% of the bankroll", record.Title, config.MaxPurchaseShare)
+	}
+
+	if config.DailySpendLimit > 0 && r.spentSince(now.Add(-24*time.Hour))+price > config.DailySpendLimit {
+		return errors.New("Daily spend limit reached, skipping " + record.Title)
+	}
+
+	if config.WeeklySpendLimit > 0 && r.spentSince(now.Add(-7*24*time.Hour))+price > config.WeeklySpendLimit {
+		return errors.New("Weekly spend limit reached, skipping " + record.Title)
+	}
+
+	heldSince := holdingSince(now)
+
+	if config.MaxItemHoldings > 0 {
+		holdings := r.history.filter(func(held *TradeRecord) bool {
+			return held.IsBot() && held.IsHeld() && held.CreatedAt.After(heldSince) && held.Title == record.Title
+		})
+
+		if len(holdings) >= config.MaxItemHoldings {
+			return fmt.Errorf("Already holding %d of %s", len(holdings), record.Title)
+		}
+	}
+
+	if config.MaxCategoryExposure > 0 && record.Category != "" {
+		exposure := 0.0
+		for _, held := range r.history.filter(func(held *TradeRecord) bool {
+			return held.IsBot() && held.IsHeld() && held.CreatedAt.After(heldSince) && held.Category == record.Category
+		}) {
+			exposure += toUsd(held.Price)
+		}
+
+		if exposure+price > config.MaxCategoryExposure {
+			return fmt.Errorf("Exposure limit reached for category %s, skipping %s", record.Category, record.Title)
+		}
+	}
+
+	return nil
+}
+
+// Check tests a purchase against the spending limits without recording it
+func (r *RiskManager) Check(record TradeRecord, bankroll Money) error {
+	r.history.mutex.Lock()
+	defer r.history.mutex.Unlock()
+
+	return r.check(&record, bankroll)
+}
+
+// Reserve checks a purchase against the spending limits and records it as pending if allowed, so concurrent purchases can't both pass
+func (r *RiskManager) Reserve(record TradeRecord, bankroll Money) error {
+	r.history.mutex.Lock()
+	defer r.history.mutex.Unlock()
+
+	if err := r.check(&record, bankroll); err != nil {
+		return err
+	}
+
+	if record.Status == "" {
+		record.Status = TRADE_PENDING
+	}
+	r.history.add(record)
+
+	return nil
+}
+
+// RemainingBudget returns the USD left to spend today and this week, which is negative once overspent
+func (r *RiskManager) RemainingBudget() (Budget, Budget) {
+	r.history.mutex.Lock()
+	defer r.history.mutex.Unlock()
+
+	now := time.Now()
+	var daily, weekly Budget
+
+	if config.DailySpendLimit > 0 {
+		daily = Budget{Remaining: config.DailySpendLimit - r.spentSince(now.Add(-24*time.Hour)), Limited: true}
+	}
+
+	if config.WeeklySpendLimit > 0 {
+		weekly = Budget{Remaining: config.WeeklySpendLimit - r.spentSince(now.Add(-7*24*time.Hour)), Limited: true}
+	}
+
+	return daily, weekly
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Lower values are served first
+const (
+	PRIORITY_PURCHASE = iota
+	PRIORITY_ACCOUNT
+	PRIORITY_POLL
+	PRIORITY_SWEEP
+	priorityCount
+)
+
+const DEFAULT_DMARKET_REQUEST_INTERVAL = 500
+const MAX_DMARKET_REQUEST_INTERVAL = time.Minute
+const SCHEDULER_POLL_INTERVAL = 50 * time.Millisecond
+const MAX_POLL_DELAY = 10 * time.Minute
+
+// DmarketScheduler shares one request budget between every Dmarket caller, backing off on errors and rate limits
+type DmarketScheduler struct {
+	mutex       sync.Mutex
+	minInterval time.Duration
+	interval    time.Duration
+	next        time.Time
+	// Set from Retry-After when rate limited
+	blockedUntil time.Time
+	waiting      [priorityCount]int
+}
+
+var scheduler = NewDmarketScheduler(DEFAULT_DMARKET_REQUEST_INTERVAL)
+
+type priorityKey struct{}
+
+// PriorityContext returns a context for Dmarket requests of the given priority
+func PriorityContext(priority int) context.Context {
+	return context.WithValue(context.Background(), priorityKey{}, priority)
+}
+
+func priorityOf(ctx context.Context) int {
+	if priority, ok := ctx.Value(priorityKey{}).(int); ok {
+		return priority
+	}
+
+	return PRIORITY_ACCOUNT
+}
+
+func NewDmarketScheduler(intervalMs int) *DmarketScheduler {
+	if intervalMs <= 0 {
+		intervalMs = DEFAULT_DMARKET_REQUEST_INTERVAL
+	}
+
+	interval := time.Duration(intervalMs) * time.Millisecond
+	return &DmarketScheduler{minInterval: interval, interval: interval}
+}
+
+// higherPriorityWaiting must be called with the mutex held
+func (s *DmarketScheduler) higherPriorityWaiting(priority int) bool {
+	for i := 0; i < priority; i++ {
+		if s.waiting[i] > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Wait blocks until a request with the priority of the context may be sent
+func (s *DmarketScheduler) Wait(ctx context.Context) error {
+	return s.Acquire(ctx, priorityOf(ctx))
+}
+
+// Acquire blocks until a request of the given priority may be sent, or the context is done
+func (s *DmarketScheduler) Acquire(ctx context.Context, priority int) error {
+	s.mutex.Lock()
+	s.waiting[priority]++
+
+	for {
+		if err := ctx.Err(); err != nil {
+			s.waiting[priority]--
+			s.mutex.Unlock()
+			return err
+		}
+
+		now := time.Now()
+		ready := s.next
+		if s.blockedUntil.After(ready) {
+			ready = s.blockedUntil
+		}
+
+		if !s.higherPriorityWaiting(priority) && !now.Before(ready) {
+			s.next = now.Add(s.interval)
+			s.waiting[priority]--
+			s.mutex.Unlock()
+			return nil
+		}
+
+		// Sleep in short steps so a purchase arriving meanwhile overtakes us
+		wait := ready.Sub(now)
+		if wait <= 0 || wait > SCHEDULER_POLL_INTERVAL {
+			wait = SCHEDULER_POLL_INTERVAL
+		}
+
+		s.mutex.Unlock()
+		time.Sleep(wait)
+		s.mutex.Lock()
+	}
+}
+
+// Observe adjusts the request rate from the outcome of a request
+func (s *DmarketScheduler) Observe(response *http.Response, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err == nil && response.StatusCode == http.StatusTooManyRequests {
+		retryAfter := parseRetryAfter(response.Header.Get("Retry-After"))
+		if retryAfter <= 0 {
+			retryAfter = s.interval * 2
+		}
+
+		s.blockedUntil = time.Now().Add(retryAfter)
+		WarningLogger.Println("Dmarket rate limit hit, pausing requests for", retryAfter)
+	}
+
+	if err != nil || response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500 {
+		s.interval *= 2
+		if s.interval > MAX_DMARKET_REQUEST_INTERVAL {
+			s.interval = MAX_DMARKET_REQUEST_INTERVAL
+		}
+		return
+	}
+
+	// Recover gradually rather than jumping straight back to the full rate
+	s.interval = s.interval * 9 / 10
+	if s.interval < s.minInterval {
+		s.interval = s.minInterval
+	}
+}
+
+// PollDelay stretches a poller's delay by how far the scheduler has backed off, up to MAX_POLL_DELAY
+func (s *DmarketScheduler) PollDelay(delayMs int) time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// In floating point, as the product of two durations overflows
+	backoff := float64(s.interval) / float64(s.minInterval)
+	delay := time.Duration(float64(time.Duration(delayMs)*time.Millisecond) * backoff)
+	if delay > MAX_POLL_DELAY {
+		delay = MAX_POLL_DELAY
+	}
+
+	return delay
+}
+
+func (s *DmarketScheduler) Interval() time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.interval
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
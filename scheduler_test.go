@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPollDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		intervalMs int
+		// Failed requests before asking for the delay, each doubling the interval
+		failures int
+		delayMs  int
+		want     time.Duration
+	}{
+		{"not backed off", 500, 0, 3000, 3 * time.Second},
+		{"backed off once", 500, 1, 10000, 20 * time.Second},
+		{"3s at a 4s interval", 1000, 2, 3000, 12 * time.Second},
+		{"10s at a 1s interval", 500, 1, 10000, 20 * time.Second},
+		{"capped", 500, 10, 10000, MAX_POLL_DELAY},
+		{"zero delay", 500, 3, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewDmarketScheduler(test.intervalMs)
+			for i := 0; i < test.failures; i++ {
+				s.Observe(nil, errors.New("connection reset"))
+			}
+
+			if got := s.PollDelay(test.delayMs); got != test.want {
+				t.Errorf("PollDelay(%d) at interval %v = %v, want %v", test.delayMs, s.Interval(), got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+const SEEN_FILE = "seen.json"
+const DEFAULT_SEEN_TTL = 24 * 60
+const SEEN_SAVE_INTERVAL = 30 * time.Second
+
+// Offers we couldn't afford are only skipped this long, to be evaluated again once the balance may have recovered
+const SEEN_UNAFFORDABLE_TTL = 5 * time.Minute
+
+type SeenEntry struct {
+	Price   string    `json:"price"`
+	Expires time.Time `json:"expires"`
+}
+
+// SeenIndex remembers which listings at which price have already been evaluated, persisted across restarts
+type SeenIndex struct {
+	mutex sync.Mutex
+	path  string
+	ttl   time.Duration
+	// Last seen price of each offer ID
+	entries map[string]SeenEntry
+	dirty   bool
+}
+
+var seen *SeenIndex
+
+func NewSeenIndex(path string, ttlMinutes int) *SeenIndex {
+	if ttlMinutes <= 0 {
+		ttlMinutes = DEFAULT_SEEN_TTL
+	}
+
+	return &SeenIndex{
+		path:    path,
+		ttl:     time.Duration(ttlMinutes) * time.Minute,
+		entries: make(map[string]SeenEntry),
+	}
+}
+
+func (s *SeenIndex) Load() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(file, &s.entries); err == nil {
+		return nil
+	}
+
+	// Older versions only stored when each offer expires. Its price is unknown, so it matches any price until it expires.
+	var expiries map[string]time.Time
+	if json.Unmarshal(file, &expiries) != nil {
+		return err
+	}
+
+	s.entries = make(map[string]SeenEntry)
+	for offerId, expires := range expiries {
+		s.entries[offerId] = SeenEntry{Expires: expires}
+	}
+	s.dirty = true
+
+	return nil
+}
+
+// Save drops expired entries and writes the rest to disk, if anything was marked since the last save
+func (s *SeenIndex) Save() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.dirty {
+		return
+	}
+	s.dirty = false
+
+	now := time.Now()
+	for offerId, entry := range s.entries {
+		if now.After(entry.Expires) {
+			delete(s.entries, offerId)
+		}
+	}
+
+	file, err := json.Marshal(s.entries)
+	if err != nil {
+		ErrorLogger.Println("Failed to encode seen listings: " + err.Error())
+		return
+	}
+
+	if err = ioutil.WriteFile(s.path, file, 0666); err != nil {
+		ErrorLogger.Println("Failed to save seen listings: " + err.Error())
+	}
+}
+
+func (s *SeenIndex) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.entries)
+}
+
+// Check returns false if a listing was already seen at this price.
+// If the offer was seen at a different price, that price is also returned.
+func (s *SeenIndex) Check(offerId string, price string) (bool, string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.entries[offerId]
+	if !ok || time.Now().After(entry.Expires) {
+		return true, ""
+	}
+
+	if entry.Price == price || entry.Price == "" {
+		return false, ""
+	}
+
+	return true, entry.Price
+}
+
+// Mark records a listing as seen at a price, once a decision has been made about it
+func (s *SeenIndex) Mark(offerId string, price string) {
+	s.MarkFor(offerId, price, s.ttl)
+}
+
+// MarkFor records a listing as seen at a price for a shorter time than the TTL, e.g. to retry it later
+func (s *SeenIndex) MarkFor(offerId string, price string, ttl time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries[offerId] = SeenEntry{Price: price, Expires: time.Now().Add(ttl)}
+	s.dirty = true
+}
+
+func RunSeenSaver() {
+	ticker := time.Tick(SEEN_SAVE_INTERVAL)
+	for range ticker {
+		seen.Save()
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSeenIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		price    string
+		isNew    bool
+		previous string
+	}{
+		{"same price", time.Hour, "1000", false, ""},
+		{"price drop", time.Hour, "900", true, "1000"},
+		{"expired", -time.Second, "1000", true, ""},
+		{"expired price drop", -time.Second, "900", true, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			index := NewSeenIndex(filepath.Join(t.TempDir(), SEEN_FILE), 60)
+			index.MarkFor("offer", "1000", test.ttl)
+
+			isNew, previous := index.Check("offer", test.price)
+			if isNew != test.isNew || previous != test.previous {
+				t.Errorf("Check(%q) = %v, %q, want %v, %q", test.price, isNew, previous, test.isNew, test.previous)
+			}
+		})
+	}
+
+	index := NewSeenIndex(filepath.Join(t.TempDir(), SEEN_FILE), 60)
+	if isNew, _ := index.Check("offer", "1000"); !isNew {
+		t.Error("Check() of an unseen offer = false")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Delivery probability assumed for sellers without Dmarket delivery stats
+const UNKNOWN_DELIVERY_RATE = 0.8
+
+// How many of our own trades with a seller weigh as much as their Dmarket delivery rate
+const SELLER_PRIOR_WEIGHT = 5
+
+// Delivery time after which a seller counts as slow when no P2P trade timeout is configured
+const SLOW_DELIVERY = 12 * time.Hour
+
+// SellerOutcomes counts how our past P2P purchases from a seller ended
+type SellerOutcomes struct {
+	Delivered   int
+	Undelivered int
+}
+
+// SellerIndex caches the outcomes of every seller, rebuilt only when the history has changed
+type SellerIndex struct {
+	mutex    sync.Mutex
+	source   *TradeHistory
+	version  int
+	outcomes map[string]SellerOutcomes
+}
+
+var sellers = &SellerIndex{}
+
+func (s *SellerIndex) Get(sellerId string) SellerOutcomes {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if version := history.Version(); s.source != history || version != s.version {
+		s.outcomes = make(map[string]SellerOutcomes)
+		for _, record := range history.Filter(func(record *TradeRecord) bool {
+			return record.Market == MARKET_DMARKET && record.SellerID != "" && record.Type == "p2p"
+		}) {
+			outcomes := s.outcomes[record.SellerID]
+			switch record.Status {
+			case TRADE_COMPLETED, TRADE_SOLD:
+				outcomes.Delivered++
+			case TRADE_CANCELLED, TRADE_TIMED_OUT:
+				outcomes.Undelivered++
+			}
+			s.outcomes[record.SellerID] = outcomes
+		}
+		s.source, s.version = history, version
+	}
+
+	return s.outcomes[sellerId]
+}
+
+func GetSellerOutcomes(sellerId string) SellerOutcomes {
+	return sellers.Get(sellerId)
+}
+
+func IsSellerBlocked(sellerId string) bool {
+	for _, blocked := range config.SellerBlocklist {
+		if blocked == sellerId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetSellerRisk estimates the chance, from 0 to 1, that the seller of a P2P offer never sends the trade.
+// Dmarket's delivery rate is the prior, updated by our own outcomes with the seller, and slow sellers add to the risk.
+func GetSellerRisk(product *dmarket.Product) float64 {
+	if GetDmarketOfferType(product) != "p2p" {
+		return 0
+	}
+
+	deliveryRate := UNKNOWN_DELIVERY_RATE
+	if rate, ok := product.DeliveryRate(); ok {
+		deliveryRate = math.Min(math.Max(rate, 0), 1)
+	}
+
+	// Beta prior worth SELLER_PRIOR_WEIGHT trades, so new sellers get the benefit of the doubt and a single cancellation doesn't condemn one
+	outcomes := GetSellerOutcomes(product.OwnerDetails.ID)
+	rate := (float64(outcomes.Delivered) + SELLER_PRIOR_WEIGHT*deliveryRate) / float64(outcomes.Delivered+outcomes.Undelivered+SELLER_PRIOR_WEIGHT)
+
+	slow := SLOW_DELIVERY
+	if config.P2PTradeTimeout > 0 {
+		slow = time.Duration(config.P2PTradeTimeout) * time.Minute
+	}
+
+	// Slow sellers tie up the balance for longer and are likelier to hit the trade timeout
+	slowness := 0.0
+	if minutes, err := strconv.ParseFloat(product.DeliveryStats.Time, 64); err == nil {
+		slowness = math.Min(minutes/slow.Minutes(), 1)
+	}
+
+	return 1 - rate*(1-slowness/2)
+}
+
+// CheckSeller reports whether the seller of an offer may be bought from, and the extra profit percentage their risk requires
+func CheckSeller(product *dmarket.Product) (bool, float64) {
+	if GetDmarketOfferType(product) != "p2p" {
+		return true, 0
+	}
+
+	if IsSellerBlocked(product.OwnerDetails.ID) {
+		InfoLogger.Println("Skipping", product.Title, "from blocked seller", product.OwnerDetails.ID)
+		return false, 0
+	}
+
+	sellerRisk := GetSellerRisk(product)
+	if config.MaxSellerRisk > 0 && sellerRisk > config.MaxSellerRisk {
+		InfoLogger.Printf("Skipping %s, seller %s risk %.2f is above the maximum\n", product.Title, product.OwnerDetails.ID, sellerRisk)
+		return false, 0
+	}
+
+	return true, sellerRisk * config.SellerRiskMargin
+}
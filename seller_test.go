@@ -0,0 +1,59 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestGetSellerRisk(t *testing.T) {
+	tests := []struct {
+		name        string
+		rate        string
+		delivered   int
+		undelivered int
+		want        float64
+		allowed     bool
+	}{
+		{"new seller without stats", "", 0, 0, 0.2, true},
+		{"one cancellation without stats", "", 0, 1, 1 - 4.0/6, true},
+		{"mostly delivered without stats", "", 10, 1, 1 - 14.0/16, true},
+		{"new seller with stats", "95", 0, 0, 0.05, true},
+		{"one cancellation with stats", "95", 0, 1, 1 - 4.75/6, true},
+		{"mostly delivered with stats", "95", 10, 1, 1 - 14.75/16, true},
+		{"repeatedly undelivered", "", 0, 4, 1 - 4.0/9, false},
+		{"poor stats", "40", 0, 0, 0.6, false},
+	}
+
+	previous := config
+	config.MaxSellerRisk = 0.5
+	config.P2PTradeTimeout = 0
+	t.Cleanup(func() { config = previous })
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTempHistory(t)
+
+			for i := 0; i < test.delivered+test.undelivered; i++ {
+				status := TRADE_COMPLETED
+				if i >= test.delivered {
+					status = TRADE_CANCELLED
+				}
+				history.Add(TradeRecord{ID: "offer-" + strconv.Itoa(i), Market: MARKET_DMARKET, Type: "p2p", SellerID: "seller", Status: status})
+			}
+
+			var product dmarket.Product
+			product.Type = "p2p"
+			product.OwnerDetails.ID = "seller"
+			product.DeliveryStats.Rate = test.rate
+
+			if risk := GetSellerRisk(&product); math.Abs(risk-test.want) > 1e-9 {
+				t.Errorf("GetSellerRisk() = %.4f, want %.4f", risk, test.want)
+			}
+			if allowed, _ := CheckSeller(&product); allowed != test.allowed {
+				t.Errorf("CheckSeller() allowed = %v, want %v", allowed, test.allowed)
+			}
+		})
+	}
+}
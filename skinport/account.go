@@ -0,0 +1,60 @@
+package skinport
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Login signs in with a password, solved reCAPTCHA token and the session's CSRF token.
+// A State of 8 with a Key means Skinport emailed a link, which has to be passed to VerifyLogin.
+func (s *Session) Login(ctx context.Context, email string, password string, captcha string) (LoginResponse, error) {
+	if err := s.Visit(ctx); err != nil {
+		return LoginResponse{}, err
+	}
+
+	form := url.Values{}
+	form.Set("email", email)
+	form.Set("password", password)
+	form.Set("g-recaptcha-response", captcha)
+
+	var loginObj LoginResponse
+	err := s.post(ctx, "/api/auth/login", form, &loginObj)
+	s.invalidate()
+
+	return loginObj, err
+}
+
+// VerifyLogin opens the link from Skinport's login email
+func (s *Session) VerifyLogin(ctx context.Context, authUrl string) (AuthResponse, error) {
+	var authObj AuthResponse
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authUrl, nil)
+	if err != nil {
+		return authObj, err
+	}
+
+	err = s.do(req, &authObj)
+	s.invalidate()
+
+	return authObj, err
+}
+
+// User fetches the logged in account, failing with ErrMustLogin once the session has expired
+func (s *Session) User(ctx context.Context) (User, error) {
+	var authObj AuthResponse
+	err := s.get(ctx, "/api/user", &authObj)
+
+	return authObj.User, err
+}
+
+// Balance returns the account balance in cents of the account currency
+func (s *Session) Balance(ctx context.Context) (int, string, error) {
+	user, err := s.User(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+
+	currency, _, err := s.Rates(ctx)
+	return user.Balance, currency, err
+}
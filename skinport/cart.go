@@ -0,0 +1,49 @@
+package skinport
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// CartSale adds a sale to the cart at the given price in cents of the account currency, which has to match the listing
+type CartSale struct {
+	SaleID string
+	Price  int
+}
+
+func (s *Session) AddToCart(ctx context.Context, sales ...CartSale) error {
+	form := url.Values{}
+	for i, sale := range sales {
+		form.Set("sales["+strconv.Itoa(i)+"][id]", sale.SaleID)
+		form.Set("sales["+strconv.Itoa(i)+"][price]", strconv.Itoa(sale.Price))
+	}
+
+	var result Result
+	return s.post(ctx, "/api/cart/add", form, &result)
+}
+
+func (s *Session) RemoveFromCart(ctx context.Context, saleIds ...string) error {
+	form := url.Values{}
+	for i, saleId := range saleIds {
+		form.Set("sales["+strconv.Itoa(i)+"]", saleId)
+	}
+
+	var result Result
+	return s.post(ctx, "/api/cart/remove", form, &result)
+}
+
+func (s *Session) Cart(ctx context.Context) ([]CartItem, error) {
+	var cartObj CartResponse
+	err := s.get(ctx, "/api/cart", &cartObj)
+
+	return cartObj.Items, err
+}
+
+// Orders fetches the account's recent checkouts, newest first
+func (s *Session) Orders(ctx context.Context) ([]Order, error) {
+	var ordersObj OrdersResponse
+	err := s.get(ctx, "/api/orders", &ordersObj)
+
+	return ordersObj.Orders, err
+}
@@ -0,0 +1,154 @@
+package skinport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const COOKIE_DOMAIN = "skinport.com"
+
+// exportedCookie is a cookie as exported by browser extensions such as Cookie-Editor, or found in a HAR response
+type exportedCookie struct {
+	Domain string `json:"domain"`
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Path   string `json:"path"`
+}
+
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				URL     string           `json:"url"`
+				Cookies []exportedCookie `json:"cookies"`
+			} `json:"request"`
+			Response struct {
+				Cookies []exportedCookie `json:"cookies"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+func isSkinportDomain(domain string) bool {
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+	return domain == COOKIE_DOMAIN || strings.HasSuffix(domain, "."+COOKIE_DOMAIN)
+}
+
+// ParseCookieExport extracts the skinport.com cookies from a Netscape cookies.txt, a JSON cookie export or a HAR file
+func ParseCookieExport(data []byte) ([]*http.Cookie, error) {
+	data = bytes.TrimSpace(data)
+
+	var cookies []*http.Cookie
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		cookies, err = parseJsonCookies(data)
+	case bytes.HasPrefix(data, []byte("{")):
+		cookies, err = parseHarCookies(data)
+	default:
+		cookies, err = parseNetscapeCookies(data)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if len(cookies) == 0 {
+		return nil, errors.New("No " + COOKIE_DOMAIN + " cookies found")
+	}
+
+	return cookies, nil
+}
+
+func newCookie(name string, value string, path string) *http.Cookie {
+	if path == "" {
+		path = "/"
+	}
+
+	return &http.Cookie{Name: name, Value: value, Path: path, Domain: "." + COOKIE_DOMAIN}
+}
+
+func parseJsonCookies(data []byte) ([]*http.Cookie, error) {
+	var exported []exportedCookie
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, err
+	}
+
+	var cookies []*http.Cookie
+	for _, cookie := range exported {
+		if isSkinportDomain(cookie.Domain) {
+			cookies = append(cookies, newCookie(cookie.Name, cookie.Value, cookie.Path))
+		}
+	}
+
+	return cookies, nil
+}
+
+// parseHarCookies collects the cookies sent to and set by skinport.com, later entries overriding earlier ones
+func parseHarCookies(data []byte) ([]*http.Cookie, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	var names []string
+	set := func(name string, value string) {
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = value
+	}
+
+	for _, entry := range har.Log.Entries {
+		requestUrl, err := url.Parse(entry.Request.URL)
+		if err != nil || !isSkinportDomain(requestUrl.Hostname()) {
+			continue
+		}
+
+		for _, cookie := range entry.Request.Cookies {
+			set(cookie.Name, cookie.Value)
+		}
+		for _, cookie := range entry.Response.Cookies {
+			if cookie.Domain == "" || isSkinportDomain(cookie.Domain) {
+				set(cookie.Name, cookie.Value)
+			}
+		}
+	}
+
+	cookies := make([]*http.Cookie, 0, len(names))
+	for _, name := range names {
+		cookies = append(cookies, newCookie(name, values[name], "/"))
+	}
+
+	return cookies, nil
+}
+
+// parseNetscapeCookies reads the tab separated cookies.txt format: domain, subdomains flag, path, secure, expiry, name, value
+func parseNetscapeCookies(data []byte) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			return nil, errors.New("Invalid cookies.txt line: " + line)
+		}
+
+		if isSkinportDomain(fields[0]) {
+			cookies = append(cookies, newCookie(fields[5], fields[6], fields[2]))
+		}
+	}
+
+	return cookies, scanner.Err()
+}
@@ -0,0 +1,116 @@
+package skinport
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCookieExport(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		// Expected cookies as name=value@path, in order
+		cookies []string
+		wantErr bool
+	}{
+		{
+			name: "netscape",
+			data: "# Netscape HTTP Cookie File\n" +
+				".skinport.com\tTRUE\t/\tTRUE\t1700000000\tconnect.sid\tabc\n" +
+				"skinport.com\tFALSE\t/api\tFALSE\t0\tlang\ten\n" +
+				".example.com\tTRUE\t/\tFALSE\t0\tother\tx\n",
+			cookies: []string{"connect.sid=abc@/", "lang=en@/api"},
+		},
+		{
+			name: "netscape httponly and crlf",
+			data: "#HttpOnly_.skinport.com\tTRUE\t/\tTRUE\t1700000000\tconnect.sid\tabc\r\n" +
+				"\r\n" +
+				"www.skinport.com\tFALSE\t/\tTRUE\t0\tcf_clearance\tdef\r\n",
+			cookies: []string{"connect.sid=abc@/", "cf_clearance=def@/"},
+		},
+		{
+			name:    "netscape lookalike domain",
+			data:    "notskinport.com\tFALSE\t/\tFALSE\t0\tconnect.sid\tabc\n",
+			wantErr: true,
+		},
+		{
+			name:    "netscape short line",
+			data:    ".skinport.com\tTRUE\t/\tTRUE\tconnect.sid\n",
+			wantErr: true,
+		},
+		{
+			name: "json",
+			data: `[
+				{"domain": ".skinport.com", "name": "connect.sid", "value": "abc", "path": "/"},
+				{"domain": "Skinport.com", "name": "lang", "value": "en"},
+				{"domain": ".example.com", "name": "other", "value": "x", "path": "/"}
+			]`,
+			cookies: []string{"connect.sid=abc@/", "lang=en@/"},
+		},
+		{
+			name:    "json without skinport cookies",
+			data:    `[{"domain": ".example.com", "name": "other", "value": "x"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			data:    `[{"domain": ".skinport.com"`,
+			wantErr: true,
+		},
+		{
+			name: "har",
+			data: `{"log": {"entries": [
+				{
+					"request": {"url": "https://skinport.com/api/user", "cookies": [{"name": "connect.sid", "value": "old"}, {"name": "lang", "value": "en"}]},
+					"response": {"cookies": [{"name": "connect.sid", "value": "new", "domain": ".skinport.com"}, {"name": "tracker", "value": "t", "domain": ".example.com"}]}
+				},
+				{
+					"request": {"url": "https://example.com/", "cookies": [{"name": "other", "value": "x"}]},
+					"response": {"cookies": []}
+				},
+				{
+					"request": {"url": "https://skinport.com/api/cart", "cookies": []},
+					"response": {"cookies": [{"name": "cf_clearance", "value": "def"}]}
+				}
+			]}}`,
+			cookies: []string{"connect.sid=new@/", "lang=en@/", "cf_clearance=def@/"},
+		},
+		{
+			name:    "har without skinport entries",
+			data:    `{"log": {"entries": [{"request": {"url": "https://example.com/", "cookies": [{"name": "other", "value": "x"}]}}]}}`,
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			data:    "  \n",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cookies, err := ParseCookieExport([]byte(test.data))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ParseCookieExport succeeded with %d cookies, want an error", len(cookies))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCookieExport failed: %v", err)
+			}
+
+			var got []string
+			for _, cookie := range cookies {
+				if cookie.Domain != "."+COOKIE_DOMAIN {
+					t.Errorf("cookie %s has domain %q, want .%s", cookie.Name, cookie.Domain, COOKIE_DOMAIN)
+				}
+				got = append(got, cookie.Name+"="+cookie.Value+"@"+cookie.Path)
+			}
+
+			if strings.Join(got, ", ") != strings.Join(test.cookies, ", ") {
+				t.Errorf("ParseCookieExport = %v, want %v", got, test.cookies)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package skinport
+
+import "errors"
+
+var (
+	// ErrMustLogin is returned when the session has expired
+	ErrMustLogin = errors.New("Skinport session expired")
+	// ErrItemNotListed is returned when a sale is no longer available
+	ErrItemNotListed = errors.New("Skinport item no longer listed")
+)
+
+// Error is a failure reported by Skinport in the message of a response
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return "Skinport error: " + e.Message
+}
+
+func newError(message string) error {
+	switch message {
+	case "MUST_LOGIN":
+		return ErrMustLogin
+	case "ITEM_NOT_LISTED":
+		return ErrItemNotListed
+	}
+
+	return &Error{Message: message}
+}
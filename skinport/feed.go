@@ -0,0 +1,49 @@
+package skinport
+
+import (
+	"context"
+	"csgoTrader/socketio"
+	"encoding/json"
+	"net/http"
+)
+
+const SALE_FEED_URL = "wss://skinport.com/socket.io/?EIO=4&transport=websocket"
+const SALE_FEED_EVENT = "saleFeed"
+
+// ConnectSaleFeed opens the sale feed with the session's cookies and joins the CS:GO feed.
+// Subscribe with OnSaleFeed, then call Run on the returned client.
+func (s *Session) ConnectSaleFeed(ctx context.Context) (*socketio.Client, error) {
+	header := http.Header{}
+	for _, cookie := range s.Cookies() {
+		header.Add("Cookie", cookie.String())
+	}
+
+	client, err := socketio.Dial(ctx, SALE_FEED_URL, header, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	join := map[string]interface{}{"appid": 730, "currency": "USD", "locale": "en"}
+	if err = client.Emit("saleFeedJoin", join); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// OnSaleFeed subscribes to sale feed payloads, skipping ones which fail to decode
+func OnSaleFeed(client *socketio.Client, handler func(payload SaleFeedPayload)) {
+	client.On(SALE_FEED_EVENT, func(args []json.RawMessage) {
+		if len(args) == 0 {
+			return
+		}
+
+		var payload SaleFeedPayload
+		if err := json.Unmarshal(args[0], &payload); err != nil {
+			return
+		}
+
+		handler(payload)
+	})
+}
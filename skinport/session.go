@@ -0,0 +1,202 @@
+// Package skinport is a client for Skinport's web API, authenticated with a browser session
+package skinport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const BASE_URL = "https://skinport.com"
+
+// How long the CSRF token and rates from /api/data are reused before fetching them again
+const DATA_TTL = 5 * time.Minute
+
+var defaultHeaders = map[string]string{
+	"accept":          "application/json, text/plain, */*",
+	"accept-language": "en-GB,en-US;q=0.9,en;q=0.8,lt;q=0.7",
+	"cache-control":   "no-cache",
+	"pragma":          "no-cache",
+	"referer":         "https://skinport.com/item/",
+	"user-agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/105.0.0.0 Safari/537.36",
+}
+
+// Session is a logged in Skinport browser session, owning its cookies and the CSRF token and rates tied to them
+type Session struct {
+	client *http.Client
+	jar    http.CookieJar
+
+	mutex     sync.Mutex
+	data      Data
+	fetchedAt time.Time
+}
+
+func NewSession() *Session {
+	jar, _ := cookiejar.New(nil)
+
+	return &Session{
+		client: &http.Client{Jar: jar},
+		jar:    jar,
+	}
+}
+
+func siteUrl() *url.URL {
+	site, _ := url.Parse(BASE_URL)
+	return site
+}
+
+// SetCookie sets a skinport.com cookie, e.g. connect.sid copied from a logged in browser
+func (s *Session) SetCookie(name string, value string) {
+	s.SetCookies([]*http.Cookie{{
+		Name:   name,
+		Value:  value,
+		Path:   "/",
+		Domain: ".skinport.com",
+	}})
+}
+
+func (s *Session) SetCookies(cookies []*http.Cookie) {
+	s.jar.SetCookies(siteUrl(), cookies)
+	s.invalidate()
+}
+
+func (s *Session) Cookies() []*http.Cookie {
+	return s.jar.Cookies(siteUrl())
+}
+
+// invalidate drops the cached CSRF token, which belongs to the previous session
+func (s *Session) invalidate() {
+	s.mutex.Lock()
+	s.fetchedAt = time.Time{}
+	s.mutex.Unlock()
+}
+
+// Visit loads the home page, picking up the cookies a browser would have before logging in
+func (s *Session) Visit(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, BASE_URL+"/", nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	return nil
+}
+
+func (s *Session) newRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, BASE_URL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for header, value := range defaultHeaders {
+		req.Header.Add(header, value)
+	}
+
+	return req, nil
+}
+
+// get fetches a JSON endpoint into out
+func (s *Session) get(ctx context.Context, path string, out interface{}) error {
+	req, err := s.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.do(req, out)
+}
+
+// post submits a form, adding the session's CSRF token, and decodes the response into out
+func (s *Session) post(ctx context.Context, path string, form url.Values, out interface{}) error {
+	csrf, err := s.Csrf(ctx)
+	if err != nil {
+		return err
+	}
+	form.Set("_csrf", csrf)
+
+	req, err := s.newRequest(ctx, http.MethodPost, path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return s.do(req, out)
+}
+
+func (s *Session) do(req *http.Request, out interface{}) error {
+	response, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+
+	if err != nil {
+		return err
+	}
+
+	// Skinport reports most failures in a JSON body with a message, whatever the status
+	var result Result
+	if json.Unmarshal(body, &result) == nil && !result.Success && result.Message != "" {
+		return newError(result.Message)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return errors.New("Erroneous response received: " + strconv.Itoa(response.StatusCode))
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// Data fetches the session's CSRF token, account currency, exchange rates and limits
+func (s *Session) Data(ctx context.Context) (Data, error) {
+	var data Data
+	if err := s.get(ctx, "/api/data?v=939402949c4961a7af31&t="+url.QueryEscape(time.Now().UTC().String()), &data); err != nil {
+		return data, err
+	}
+
+	s.mutex.Lock()
+	s.data = data
+	s.fetchedAt = time.Now()
+	s.mutex.Unlock()
+
+	return data, nil
+}
+
+// CachedData returns the last fetched data, fetching it again once older than DATA_TTL
+func (s *Session) CachedData(ctx context.Context) (Data, error) {
+	s.mutex.Lock()
+	data, fetchedAt := s.data, s.fetchedAt
+	s.mutex.Unlock()
+
+	if !fetchedAt.IsZero() && time.Since(fetchedAt) < DATA_TTL {
+		return data, nil
+	}
+
+	return s.Data(ctx)
+}
+
+func (s *Session) Csrf(ctx context.Context) (string, error) {
+	data, err := s.CachedData(ctx)
+	return data.Csrf, err
+}
+
+// Rates returns the account currency and the units of each currency per 1 of it
+func (s *Session) Rates(ctx context.Context) (string, map[string]float64, error) {
+	data, err := s.CachedData(ctx)
+	return data.Currency, data.Rates, err
+}
@@ -0,0 +1,142 @@
+package skinport
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"golang.org/x/crypto/scrypt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Session files start with this header and a random salt, files without it are from before the key was derived with scrypt
+const STORE_HEADER = "csgoTrader-session-v2\n"
+const STORE_SALT_SIZE = 16
+
+// scrypt cost parameters, about 100ms per derivation
+const (
+	SCRYPT_N = 1 << 15
+	SCRYPT_R = 8
+	SCRYPT_P = 1
+)
+
+type storedCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// newCipher derives an AES-256-GCM cipher from a passphrase and salt
+func newCipher(key string, salt []byte) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, errors.New("No session key set")
+	}
+
+	derived, err := scrypt.Key([]byte(key), salt, SCRYPT_N, SCRYPT_R, SCRYPT_P, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGcm(derived)
+}
+
+// newLegacyCipher is the unsalted SHA-256 key older session files were encrypted with
+func newLegacyCipher(key string) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, errors.New("No session key set")
+	}
+
+	hash := sha256.Sum256([]byte(key))
+	return newGcm(hash[:])
+}
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// Save writes the session's cookies to path, encrypted with key
+func (s *Session) Save(path string, key string) error {
+	salt := make([]byte, STORE_SALT_SIZE)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+
+	gcm, err := newCipher(key, salt)
+	if err != nil {
+		return err
+	}
+
+	var cookies []storedCookie
+	for _, cookie := range s.Cookies() {
+		cookies = append(cookies, storedCookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	plaintext, err := json.Marshal(cookies)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	file := append([]byte(STORE_HEADER), salt...)
+	return ioutil.WriteFile(path, gcm.Seal(append(file, nonce...), nonce, plaintext, nil), 0600)
+}
+
+// Load restores cookies saved with Save. A missing file is returned as is, check it with os.IsNotExist.
+// Files from older versions are still read, and are upgraded on the next Save.
+func (s *Session) Load(path string, key string) error {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var gcm cipher.AEAD
+	if bytes.HasPrefix(file, []byte(STORE_HEADER)) {
+		file = file[len(STORE_HEADER):]
+		if len(file) < STORE_SALT_SIZE {
+			return errors.New("Session file is truncated")
+		}
+
+		gcm, err = newCipher(key, file[:STORE_SALT_SIZE])
+		file = file[STORE_SALT_SIZE:]
+	} else {
+		gcm, err = newLegacyCipher(key)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(file) < gcm.NonceSize() {
+		return errors.New("Session file is truncated")
+	}
+
+	plaintext, err := gcm.Open(nil, file[:gcm.NonceSize()], file[gcm.NonceSize():], nil)
+	if err != nil {
+		return errors.New("Failed to decrypt session file, was the session key changed?")
+	}
+
+	var cookies []storedCookie
+	if err = json.Unmarshal(plaintext, &cookies); err != nil {
+		return err
+	}
+
+	jarCookies := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		jarCookies = append(jarCookies, &http.Cookie{Name: cookie.Name, Value: cookie.Value, Path: "/", Domain: ".skinport.com"})
+	}
+	s.SetCookies(jarCookies)
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package skinport
+
+import "time"
+
+// Result is the envelope of every Skinport API response
+type Result struct {
+	RequestID string `json:"requestId"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+}
+
+type Data struct {
+	RequestID string      `json:"requestId"`
+	Success   bool        `json:"success"`
+	Message   interface{} `json:"message"`
+	Csrf      string      `json:"csrf"`
+	Country   string      `json:"country"`
+	Currency  string      `json:"currency"`
+	Rate      float64     `json:"rate"`
+	// Units of each currency per 1 of the account currency
+	Rates  map[string]float64 `json:"rates"`
+	Locale string             `json:"locale"`
+	Tags   []struct {
+		Tag   string `json:"tag"`
+		Appid int    `json:"appid"`
+	} `json:"tags"`
+	// Values in cents of the account currency
+	Limits struct {
+		MinOrderValue     int `json:"minOrderValue"`
+		KycTier1PayoutMax int `json:"kycTier1PayoutMax"`
+		MinSaleValue      int `json:"minSaleValue"`
+		SaleFeeReduced    int `json:"saleFeeReduced"`
+		MaxOrderValue     int `json:"maxOrderValue"`
+		MinPayoutValue    int `json:"minPayoutValue"`
+		KycTier2PayoutMax int `json:"kycTier2PayoutMax"`
+	} `json:"limits"`
+	PaymentMethods []string      `json:"paymentMethods"`
+	Following      []interface{} `json:"following"`
+}
+
+type LoginResponse struct {
+	RequestID string `json:"requestId"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	State     int    `json:"state"`
+	Email     string `json:"email"`
+	Key       string `json:"key"`
+}
+
+type User struct {
+	ID                  int    `json:"id"`
+	Username            string `json:"username"`
+	Avatar              string `json:"avatar"`
+	SteamAccounts       int    `json:"steamAccounts"`
+	Balance             int    `json:"balance"`
+	WithdrawAble        int    `json:"withdrawAble"`
+	Trusted             bool   `json:"trusted"`
+	TwoFactor           bool   `json:"twoFactor"`
+	Password            bool   `json:"password"`
+	VoucherAccess       bool   `json:"voucherAccess"`
+	APIAccess           bool   `json:"apiAccess"`
+	BillingAddress      bool   `json:"billingAddress"`
+	Affiliate           bool   `json:"affiliate"`
+	UnreadNotifications int    `json:"unreadNotifications"`
+}
+
+type AuthResponse struct {
+	RequestID string `json:"requestId"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	User      User   `json:"user"`
+}
+
+// SaleStatus of a sale which can still be bought
+const SALE_STATUS_LISTED = "listed"
+
+type CartItem struct {
+	SaleID     int    `json:"saleId"`
+	MarketName string `json:"marketName"`
+	SalePrice  int    `json:"salePrice"`
+	Currency   string `json:"currency"`
+	SaleStatus string `json:"saleStatus"`
+}
+
+type CartResponse struct {
+	RequestID string     `json:"requestId"`
+	Success   bool       `json:"success"`
+	Message   string     `json:"message"`
+	Items     []CartItem `json:"items"`
+}
+
+// OrderStatus of a checkout which was cancelled or refunded
+const ORDER_STATUS_CANCELLED = "cancelled"
+
+type OrderItem struct {
+	SaleID     int    `json:"saleId"`
+	MarketName string `json:"marketName"`
+	SalePrice  int    `json:"salePrice"`
+}
+
+type Order struct {
+	ID       int         `json:"id"`
+	Status   string      `json:"status"`
+	Currency string      `json:"currency"`
+	Items    []OrderItem `json:"items"`
+}
+
+type OrdersResponse struct {
+	RequestID string  `json:"requestId"`
+	Success   bool    `json:"success"`
+	Message   string  `json:"message"`
+	Orders    []Order `json:"orders"`
+}
+
+type SaleFeedPayload struct {
+	EventType string `json:"eventType"`
+	Sales     []Sale `json:"sales"`
+}
+
+type Sale struct {
+	ID                   int           `json:"id"`
+	SaleID               int           `json:"saleId"`
+	ProductID            int           `json:"productId"`
+	AssetID              int           `json:"assetId"`
+	ItemID               int           `json:"itemId"`
+	Appid                int           `json:"appid"`
+	Steamid              string        `json:"steamid"`
+	URL                  string        `json:"url"`
+	Family               string        `json:"family"`
+	FamilyLocalized      string        `json:"family_localized"`
+	Name                 string        `json:"name"`
+	Title                string        `json:"title"`
+	Text                 string        `json:"text"`
+	MarketName           string        `json:"marketName"`
+	MarketHashName       string        `json:"marketHashName"`
+	Color                string        `json:"color"`
+	BgColor              interface{}   `json:"bgColor"`
+	Image                string        `json:"image"`
+	Classid              string        `json:"classid"`
+	Assetid              string        `json:"assetid"`
+	Lock                 time.Time     `json:"lock"`
+	Version              string        `json:"version"`
+	VersionType          string        `json:"versionType"`
+	StackAble            bool          `json:"stackAble"`
+	SuggestedPrice       int           `json:"suggestedPrice"`
+	SalePrice            int           `json:"salePrice"`
+	Currency             string        `json:"currency"`
+	SaleStatus           string        `json:"saleStatus"`
+	SaleType             string        `json:"saleType"`
+	Category             string        `json:"category"`
+	CategoryLocalized    string        `json:"category_localized"`
+	SubCategory          string        `json:"subCategory"`
+	SubCategoryLocalized string        `json:"subCategory_localized"`
+	Pattern              int           `json:"pattern"`
+	Finish               int           `json:"finish"`
+	CustomName           interface{}   `json:"customName"`
+	Wear                 float64       `json:"wear"`
+	Link                 string        `json:"link"`
+	Type                 string        `json:"type"`
+	Exterior             string        `json:"exterior"`
+	Quality              string        `json:"quality"`
+	Rarity               string        `json:"rarity"`
+	RarityLocalized      string        `json:"rarity_localized"`
+	RarityColor          string        `json:"rarityColor"`
+	Collection           interface{}   `json:"collection"`
+	CollectionLocalized  interface{}   `json:"collection_localized"`
+	Stickers             []interface{} `json:"stickers"`
+	CanHaveScreenshots   bool          `json:"canHaveScreenshots"`
+	Screenshots          []interface{} `json:"screenshots"`
+	Souvenir             bool          `json:"souvenir"`
+	Stattrak             bool          `json:"stattrak"`
+	Tags                 []struct {
+		Name          string `json:"name"`
+		NameLocalized string `json:"name_localized"`
+	} `json:"tags"`
+	OwnItem bool `json:"ownItem"`
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"context"
+	"csgoTrader/skinport"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Additions within this window are sent in a single cart ready notification
+const CART_NOTIFY_DELAY = 5 * time.Second
+
+var (
+	ErrCartFull      = errors.New("Skinport cart is full")
+	ErrMaxOrderValue = errors.New("Skinport cart would exceed the maximum order value")
+)
+
+type CartEntry struct {
+	SaleID string
+	Title  string
+	// Price in the account currency
+	Price   Money
+	AddedAt time.Time
+	// Whether the bot carted the item, items added by hand count towards the limits but are left alone
+	Tracked bool
+}
+
+// SkinportCart keeps track of the Skinport cart, enforcing its limits and removing sold or stale items
+type SkinportCart struct {
+	// Held while adding or syncing so the cart doesn't change under either
+	addMutex sync.Mutex
+
+	mutex   sync.Mutex
+	entries map[string]CartEntry
+	// Entries the sale feed reported as sold, no longer counted towards the limits until the next sync settles them
+	sold        map[string]CartEntry
+	startedAt   time.Time
+	changed     bool
+	notifyTimer *time.Timer
+}
+
+var skinportCart = NewSkinportCart()
+
+func NewSkinportCart() *SkinportCart {
+	return &SkinportCart{
+		entries:   make(map[string]CartEntry),
+		sold:      make(map[string]CartEntry),
+		startedAt: time.Now(),
+	}
+}
+
+// totalCents must be called with the mutex held
+func (c *SkinportCart) totalCents() int {
+	total := 0
+	for _, entry := range c.entries {
+		total += entry.Price.Cents()
+	}
+
+	return total
+}
+
+// Contents returns the cart entries, oldest first, and their total
+func (c *SkinportCart) Contents() ([]CartEntry, Money) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries := make([]CartEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].AddedAt.Before(entries[j].AddedAt)
+	})
+
+	var currency Currency
+	if len(entries) > 0 {
+		currency = entries[0].Price.Currency
+	}
+
+	return entries, MoneyFromCents(c.totalCents(), currency)
+}
+
+// Add carts a reserved record at its price converted into the account currency at Skinport's own rate
+func (c *SkinportCart) Add(record TradeRecord) error {
+	c.addMutex.Lock()
+	defer c.addMutex.Unlock()
+
+	err := c.add(context.Background(), record)
+	if err == nil {
+		InfoLogger.Println("Added " + record.Title + " to the Skinport cart")
+		c.scheduleNotify()
+		return nil
+	}
+
+	history.SetStatus(MARKET_SKINPORT, record.ID, TRADE_FAILED)
+
+	switch err {
+	case ErrCartFull, ErrMaxOrderValue:
+		InfoLogger.Println("Not carting " + record.Title + ": " + err.Error())
+		return err
+	case skinport.ErrMustLogin:
+		err = errors.New("Login expired at ATC")
+		SkinportSessionExpired()
+	case skinport.ErrItemNotListed:
+		err = errors.New(record.ID + " is now OOS")
+	}
+
+	ReportError(err)
+	return err
+}
+
+func (c *SkinportCart) add(ctx context.Context, record TradeRecord) error {
+	data, err := skinportSession.CachedData(ctx)
+	if err != nil {
+		return err
+	}
+
+	accountPrice, err := currencies.ConvertAtMid(record.Price, Currency(data.Currency))
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	size, total := len(c.entries), c.totalCents()
+	c.mutex.Unlock()
+
+	if config.MaxCartSize > 0 && size >= config.MaxCartSize {
+		return ErrCartFull
+	}
+	if data.Limits.MaxOrderValue > 0 && total+accountPrice.Cents() > data.Limits.MaxOrderValue {
+		return ErrMaxOrderValue
+	}
+
+	if err = skinportSession.AddToCart(ctx, skinport.CartSale{SaleID: record.ID, Price: accountPrice.Cents()}); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.entries[record.ID] = CartEntry{SaleID: record.ID, Title: record.Title, Price: accountPrice, AddedAt: time.Now(), Tracked: true}
+	c.changed = true
+	c.mutex.Unlock()
+
+	return nil
+}
+
+// MarkSold moves a sale out of the cart entries, to be settled by the next sync
+func (c *SkinportCart) MarkSold(saleId string) (CartEntry, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[saleId]
+	if !ok {
+		return CartEntry{}, false
+	}
+
+	delete(c.entries, saleId)
+	c.sold[saleId] = entry
+
+	return entry, true
+}
+
+// Sync reconciles the cart with Skinport's. Sold items and items carted longer than
+// cartStaleMinutes are removed, releasing their budget. Items which left the cart are
+// completed if they are found in a Skinport order, otherwise left unknown to be checked by hand,
+// which keeps them counted towards the spend limits either way.
+func (c *SkinportCart) Sync(ctx context.Context) error {
+	c.addMutex.Lock()
+	defer c.addMutex.Unlock()
+
+	items, err := skinportSession.Cart(ctx)
+	if err != nil {
+		return err
+	}
+
+	remote := make(map[string]skinport.CartItem)
+	for _, item := range items {
+		remote[strconv.Itoa(item.SaleID)] = item
+	}
+
+	// Carted records from before this run are only known from the history
+	leftovers := history.Filter(func(record *TradeRecord) bool {
+		return record.Market == MARKET_SKINPORT && record.Status == TRADE_CARTED && record.CreatedAt.Before(c.startedAt)
+	})
+
+	staleAfter := time.Duration(config.CartStaleMinutes) * time.Minute
+	statuses := make(map[string]string)
+	var removals []string
+
+	c.mutex.Lock()
+	for saleId, entry := range c.sold {
+		if _, ok := c.entries[saleId]; !ok {
+			c.entries[saleId] = entry
+		}
+		delete(c.sold, saleId)
+	}
+
+	for _, record := range leftovers {
+		if _, ok := c.entries[record.ID]; ok {
+			continue
+		}
+
+		// Priced from the cart, or the listing price until it is found there
+		price := record.Price
+		if item, ok := remote[record.ID]; ok {
+			price = MoneyFromCents(item.SalePrice, Currency(item.Currency))
+		}
+		c.entries[record.ID] = CartEntry{SaleID: record.ID, Title: record.Title, Price: price, AddedAt: record.CreatedAt, Tracked: true}
+	}
+
+	checkedOut := false
+	for saleId, entry := range c.entries {
+		if _, ok := remote[saleId]; !ok && entry.Tracked {
+			checkedOut = true
+		}
+	}
+	c.mutex.Unlock()
+
+	ordered := make(map[string]bool)
+	if checkedOut {
+		orders, err := skinportSession.Orders(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			if order.Status == skinport.ORDER_STATUS_CANCELLED {
+				continue
+			}
+			for _, item := range order.Items {
+				ordered[strconv.Itoa(item.SaleID)] = true
+			}
+		}
+	}
+
+	c.mutex.Lock()
+	for saleId, entry := range c.entries {
+		item, ok := remote[saleId]
+		if !ok {
+			delete(c.entries, saleId)
+			if !entry.Tracked {
+				continue
+			}
+
+			if ordered[saleId] {
+				InfoLogger.Println(entry.Title + " was checked out")
+				statuses[saleId] = TRADE_COMPLETED
+			} else {
+				WarningLogger.Println(entry.Title + " left the Skinport cart without a matching order")
+				ReportError(errors.New(entry.Title + " (" + saleId + ") left the Skinport cart but no order was found for it, check it manually"))
+				statuses[saleId] = TRADE_UNKNOWN
+			}
+			continue
+		}
+
+		if item.SaleStatus != skinport.SALE_STATUS_LISTED {
+			delete(c.entries, saleId)
+			if entry.Tracked {
+				InfoLogger.Println("Carted " + entry.Title + " sold to someone else, removing it from the cart")
+				statuses[saleId] = TRADE_FAILED
+				removals = append(removals, saleId)
+			}
+			continue
+		}
+
+		if entry.Tracked && staleAfter > 0 && time.Since(entry.AddedAt) > staleAfter {
+			delete(c.entries, saleId)
+			InfoLogger.Println("Removing stale " + entry.Title + " from the Skinport cart")
+			statuses[saleId] = TRADE_CANCELLED
+			removals = append(removals, saleId)
+			continue
+		}
+
+		entry.Price = MoneyFromCents(item.SalePrice, Currency(item.Currency))
+		c.entries[saleId] = entry
+	}
+
+	for saleId, item := range remote {
+		if _, ok := c.entries[saleId]; ok || item.SaleStatus != skinport.SALE_STATUS_LISTED {
+			continue
+		}
+		if _, ok := statuses[saleId]; ok {
+			continue
+		}
+
+		c.entries[saleId] = CartEntry{
+			SaleID:  saleId,
+			Title:   item.MarketName,
+			Price:   MoneyFromCents(item.SalePrice, Currency(item.Currency)),
+			AddedAt: time.Now(),
+		}
+	}
+	c.mutex.Unlock()
+
+	for saleId, status := range statuses {
+		history.SetStatus(MARKET_SKINPORT, saleId, status)
+	}
+
+	// A cart which was below the minimum order value may have reached it with the synced prices
+	c.mutex.Lock()
+	changed := c.changed
+	c.mutex.Unlock()
+	if changed {
+		c.scheduleNotify()
+	}
+
+	if len(removals) > 0 {
+		return skinportSession.RemoveFromCart(ctx, removals...)
+	}
+
+	return nil
+}
+
+func (c *SkinportCart) scheduleNotify() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.notifyTimer == nil {
+		c.notifyTimer = time.AfterFunc(CART_NOTIFY_DELAY, c.notify)
+	}
+}
+
+// notify sends the cart ready notification once the cart reaches Skinport's minimum order value.
+// Below it the cart stays changed, so the notification is retried after the next addition or sync.
+func (c *SkinportCart) notify() {
+	c.mutex.Lock()
+	c.notifyTimer = nil
+	changed := c.changed
+	c.mutex.Unlock()
+
+	if !changed {
+		return
+	}
+
+	entries, total := c.Contents()
+	data, err := skinportSession.CachedData(context.Background())
+	if err == nil && total.Cents() < data.Limits.MinOrderValue {
+		InfoLogger.Println("Skinport cart total " + total.String() + " is below the minimum order value")
+		return
+	}
+
+	c.mutex.Lock()
+	c.changed = false
+	c.mutex.Unlock()
+
+	SendCartReady(entries, total)
+}
+
+// Status describes the cart for the status report
+func (c *SkinportCart) Status() string {
+	entries, total := c.Contents()
+	if len(entries) == 0 {
+		return "Empty"
+	}
+
+	return strconv.Itoa(len(entries)) + " items, " + total.String()
+}
+
+func RunSkinportCart(delaySeconds int) {
+	if delaySeconds <= 0 {
+		return
+	}
+
+	ticker := time.Tick(time.Duration(delaySeconds) * time.Second)
+	for range ticker {
+		err := skinportCart.Sync(context.Background())
+		if err == skinport.ErrMustLogin {
+			SkinportSessionExpired()
+		} else if err != nil {
+			ErrorLogger.Println("Failed to sync the Skinport cart: " + err.Error())
+		}
+	}
+}
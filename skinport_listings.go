@@ -0,0 +1,182 @@
+package main
+
+import (
+	"csgoTrader/skinport"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const LIQUIDITY_FILE = "liquidity.json"
+
+// Listings not seen selling within this time are dropped from the live view
+const SKINPORT_LISTING_TTL = 7 * 24 * time.Hour
+const LIQUIDITY_SAVE_INTERVAL = time.Minute
+
+const (
+	SKINPORT_EVENT_LISTED = "listed"
+	SKINPORT_EVENT_SOLD   = "sold"
+)
+
+type SkinportListing struct {
+	Title    string
+	Price    Money
+	ListedAt time.Time
+}
+
+// SaleTimeStats accumulates how long listings of an item took to sell, as a measure of its liquidity
+type SaleTimeStats struct {
+	Sales        int     `json:"sales"`
+	TotalSeconds float64 `json:"totalSeconds"`
+}
+
+func (s *SaleTimeStats) Average() time.Duration {
+	if s.Sales == 0 {
+		return 0
+	}
+
+	return time.Duration(s.TotalSeconds / float64(s.Sales) * float64(time.Second))
+}
+
+// SkinportListings is a live view of active Skinport listings built from the sale feed
+type SkinportListings struct {
+	mutex    sync.Mutex
+	path     string
+	listings map[int]SkinportListing
+	stats    map[string]*SaleTimeStats
+	dirty    bool
+}
+
+var skinportListings = NewSkinportListings(LIQUIDITY_FILE)
+
+func NewSkinportListings(path string) *SkinportListings {
+	return &SkinportListings{
+		path:     path,
+		listings: make(map[int]SkinportListing),
+		stats:    make(map[string]*SaleTimeStats),
+	}
+}
+
+func (l *SkinportListings) Listed(sales []skinport.Sale) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	for _, sale := range sales {
+		l.listings[sale.SaleID] = SkinportListing{Title: sale.MarketName, Price: GetSkinportPrice(&sale), ListedAt: now}
+	}
+}
+
+// Expire drops listings which haven't sold within SKINPORT_LISTING_TTL
+func (l *SkinportListings) Expire() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	for saleId, listing := range l.listings {
+		if now.Sub(listing.ListedAt) > SKINPORT_LISTING_TTL {
+			delete(l.listings, saleId)
+		}
+	}
+}
+
+// Sold removes sold listings, recording the time to sale of those we saw being listed
+func (l *SkinportListings) Sold(sales []skinport.Sale) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, sale := range sales {
+		listing, ok := l.listings[sale.SaleID]
+		if !ok {
+			continue
+		}
+		delete(l.listings, sale.SaleID)
+
+		stats, ok := l.stats[listing.Title]
+		if !ok {
+			stats = &SaleTimeStats{}
+			l.stats[listing.Title] = stats
+		}
+
+		stats.Sales++
+		stats.TotalSeconds += time.Since(listing.ListedAt).Seconds()
+		l.dirty = true
+	}
+}
+
+func (l *SkinportListings) Active() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return len(l.listings)
+}
+
+// AverageTimeToSale returns how long listings of an item usually take to sell on Skinport
+func (l *SkinportListings) AverageTimeToSale(title string) (time.Duration, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	stats, ok := l.stats[title]
+	if !ok || stats.Sales == 0 {
+		return 0, false
+	}
+
+	return stats.Average(), true
+}
+
+func (l *SkinportListings) Load() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	file, err := ioutil.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(file, &l.stats)
+}
+
+// Save writes the liquidity stats to disk, if any sale was recorded since the last save
+func (l *SkinportListings) Save() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.dirty {
+		return
+	}
+	l.dirty = false
+
+	file, err := json.MarshalIndent(l.stats, "", "  ")
+	if err != nil {
+		ErrorLogger.Println("Failed to encode liquidity stats: " + err.Error())
+		return
+	}
+
+	if err = ioutil.WriteFile(l.path, file, 0644); err != nil {
+		ErrorLogger.Println("Failed to save liquidity stats: " + err.Error())
+	}
+}
+
+func RunSkinportListings() {
+	ticker := time.Tick(LIQUIDITY_SAVE_INTERVAL)
+	for range ticker {
+		skinportListings.Expire()
+		skinportListings.Save()
+	}
+}
+
+// ClearSoldFromCart stops counting sales the feed reports as sold towards the cart limits.
+// The sale may have been our own checkout, so the outcome is left for the next cart sync to settle.
+func ClearSoldFromCart(sales []skinport.Sale) {
+	for _, sale := range sales {
+		if entry, ok := skinportCart.MarkSold(strconv.Itoa(sale.SaleID)); ok {
+			InfoLogger.Println("Carted " + entry.Title + " sold, leaving it to the cart sync")
+		}
+	}
+}
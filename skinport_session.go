@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"csgoTrader/skinport"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+const SESSION_FILE = "session.dat"
+
+// Held while logging in again, so an expiry noticed by several requests only prompts once
+var reloginMutex sync.Mutex
+
+// RestoreSkinportSession reuses the session saved by a previous run, only logging in when it has expired
+func RestoreSkinportSession() {
+	if config.SessionKey == "" {
+		login()
+		return
+	}
+
+	err := skinportSession.Load(SESSION_FILE, config.SessionKey)
+	if err == nil {
+		var user skinport.User
+		user, err = skinportSession.User(context.Background())
+		if err == nil {
+			InfoLogger.Println("Restored Skinport session of " + user.Username)
+			return
+		}
+
+		// Skinport being unreachable doesn't mean the session expired, the probe will find out
+		if err != skinport.ErrMustLogin {
+			ErrorLogger.Println("Failed to check the restored Skinport session: " + err.Error())
+			return
+		}
+
+		InfoLogger.Println("Saved Skinport session has expired")
+	} else if !os.IsNotExist(err) {
+		ErrorLogger.Println("Failed to restore the Skinport session: " + err.Error())
+	}
+
+	login()
+}
+
+// SaveSkinportSession encrypts the session cookies to disk, if a session key is configured
+func SaveSkinportSession() {
+	if config.SessionKey == "" {
+		return
+	}
+
+	if err := skinportSession.Save(SESSION_FILE, config.SessionKey); err != nil {
+		ErrorLogger.Println("Failed to save the Skinport session: " + err.Error())
+	}
+}
+
+// SkinportSessionExpired logs in again in the background, unless a login is already underway
+func SkinportSessionExpired() {
+	if !reloginMutex.TryLock() {
+		return
+	}
+
+	go func() {
+		defer reloginMutex.Unlock()
+
+		ReportError(errors.New("Skinport session expired, logging in again"))
+		login()
+	}()
+}
+
+// RunSessionProbe periodically checks the Skinport session is still logged in
+func RunSessionProbe(delayMinutes int) {
+	if delayMinutes <= 0 {
+		return
+	}
+
+	ticker := time.Tick(time.Duration(delayMinutes) * time.Minute)
+	for range ticker {
+		_, err := skinportSession.User(context.Background())
+		if err == skinport.ErrMustLogin {
+			SkinportSessionExpired()
+		} else if err != nil {
+			WarningLogger.Println("Failed to probe the Skinport session: " + err.Error())
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Package socketio is a minimal socket.io v5 client over engine.io v4 websockets, enough to subscribe to server events
+package socketio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// engine.io packet types
+const (
+	ENGINE_OPEN    = '0'
+	ENGINE_CLOSE   = '1'
+	ENGINE_PING    = '2'
+	ENGINE_PONG    = '3'
+	ENGINE_MESSAGE = '4'
+	ENGINE_UPGRADE = '5'
+	ENGINE_NOOP    = '6'
+)
+
+// socket.io packet types, carried in engine.io messages
+const (
+	SOCKET_CONNECT       = '0'
+	SOCKET_DISCONNECT    = '1'
+	SOCKET_EVENT         = '2'
+	SOCKET_ACK           = '3'
+	SOCKET_CONNECT_ERROR = '4'
+	SOCKET_BINARY_EVENT  = '5'
+	SOCKET_BINARY_ACK    = '6'
+)
+
+var (
+	ErrDisconnected = errors.New("socket.io server disconnected")
+	ErrClosed       = errors.New("engine.io connection closed by the server")
+)
+
+type OpenPacket struct {
+	Sid          string   `json:"sid"`
+	Upgrades     []string `json:"upgrades"`
+	PingInterval int      `json:"pingInterval"`
+	PingTimeout  int      `json:"pingTimeout"`
+	MaxPayload   int      `json:"maxPayload"`
+}
+
+// Handler receives the arguments an event was emitted with
+type Handler func(args []json.RawMessage)
+
+type Client struct {
+	conn      *websocket.Conn
+	namespace string
+	open      OpenPacket
+
+	writeMutex sync.Mutex
+
+	handlersMutex sync.RWMutex
+	handlers      map[string][]Handler
+}
+
+// Dial opens an engine.io websocket and connects to the socket.io namespace, "/" for the default one
+func Dial(ctx context.Context, url string, header http.Header, namespace string) (*Client, error) {
+	if namespace == "" {
+		namespace = "/"
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url, header)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{conn: conn, namespace: namespace, handlers: make(map[string][]Handler)}
+	if err = client.handshake(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (c *Client) handshake(ctx context.Context) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetReadDeadline(deadline)
+		defer c.conn.SetReadDeadline(time.Time{})
+	}
+
+	_, message, err := c.conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+
+	if len(message) == 0 || message[0] != ENGINE_OPEN {
+		return errors.New("Expected engine.io open packet, got " + string(message))
+	}
+	if err = json.Unmarshal(message[1:], &c.open); err != nil {
+		return err
+	}
+
+	if err = c.writeSocket(SOCKET_CONNECT, ""); err != nil {
+		return err
+	}
+
+	// Pings may arrive before the namespace is connected
+	for {
+		_, message, err = c.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		if len(message) > 0 && message[0] == ENGINE_PING {
+			if err = c.write(string(ENGINE_PONG)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		packetType, _, data, ok := c.parseSocket(message)
+		if !ok {
+			continue
+		}
+
+		switch packetType {
+		case SOCKET_CONNECT:
+			return nil
+		case SOCKET_CONNECT_ERROR:
+			return errors.New("socket.io connect error: " + data)
+		}
+	}
+}
+
+// PingTimeout is how long the connection may stay silent before it counts as dead
+func (c *Client) PingTimeout() time.Duration {
+	return time.Duration(c.open.PingInterval+c.open.PingTimeout) * time.Millisecond
+}
+
+// On subscribes to an event by name
+func (c *Client) On(event string, handler Handler) {
+	c.handlersMutex.Lock()
+	defer c.handlersMutex.Unlock()
+
+	c.handlers[event] = append(c.handlers[event], handler)
+}
+
+// Emit sends an event with JSON encoded arguments
+func (c *Client) Emit(event string, args ...interface{}) error {
+	payload, err := json.Marshal(append([]interface{}{event}, args...))
+	if err != nil {
+		return err
+	}
+
+	return c.writeSocket(SOCKET_EVENT, string(payload))
+}
+
+// Run reads packets and dispatches events until the connection fails, the server disconnects or stops pinging
+func (c *Client) Run() error {
+	for {
+		if timeout := c.PingTimeout(); timeout > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(timeout))
+		}
+
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		if len(message) == 0 {
+			continue
+		}
+
+		switch message[0] {
+		case ENGINE_PING:
+			if err = c.write(string(ENGINE_PONG)); err != nil {
+				return err
+			}
+		case ENGINE_CLOSE:
+			return ErrClosed
+		case ENGINE_MESSAGE:
+			if err = c.handleSocket(message); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *Client) handleSocket(message []byte) error {
+	packetType, _, data, ok := c.parseSocket(message)
+	if !ok {
+		return nil
+	}
+
+	switch packetType {
+	case SOCKET_DISCONNECT:
+		return ErrDisconnected
+	case SOCKET_CONNECT_ERROR:
+		return errors.New("socket.io connect error: " + data)
+	case SOCKET_EVENT:
+		var args []json.RawMessage
+		if err := json.Unmarshal([]byte(data), &args); err != nil || len(args) == 0 {
+			return nil
+		}
+
+		var event string
+		if err := json.Unmarshal(args[0], &event); err != nil {
+			return nil
+		}
+
+		c.handlersMutex.RLock()
+		handlers := c.handlers[event]
+		c.handlersMutex.RUnlock()
+
+		for _, handler := range handlers {
+			handler(args[1:])
+		}
+	}
+
+	return nil
+}
+
+// parseSocket splits an engine.io message into the socket.io packet type, ack ID and data.
+// Packets for other namespaces and binary packets, which need attachments we don't read, are skipped.
+func (c *Client) parseSocket(message []byte) (byte, string, string, bool) {
+	if len(message) < 2 || message[0] != ENGINE_MESSAGE {
+		return 0, "", "", false
+	}
+
+	packetType := message[1]
+	rest := string(message[2:])
+
+	if packetType == SOCKET_BINARY_EVENT || packetType == SOCKET_BINARY_ACK {
+		return 0, "", "", false
+	}
+
+	namespace := "/"
+	if strings.HasPrefix(rest, "/") {
+		end := strings.Index(rest, ",")
+		if end < 0 {
+			namespace, rest = rest, ""
+		} else {
+			namespace, rest = rest[:end], rest[end+1:]
+		}
+	}
+
+	if namespace != c.namespace {
+		return 0, "", "", false
+	}
+
+	ackEnd := 0
+	for ackEnd < len(rest) && rest[ackEnd] >= '0' && rest[ackEnd] <= '9' {
+		ackEnd++
+	}
+
+	return packetType, rest[:ackEnd], rest[ackEnd:], true
+}
+
+func (c *Client) writeSocket(packetType byte, data string) error {
+	namespace := ""
+	if c.namespace != "/" && c.namespace != "" {
+		namespace = c.namespace + ","
+	}
+
+	return c.write(string(ENGINE_MESSAGE) + string(packetType) + namespace + data)
+}
+
+func (c *Client) write(packet string) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	return c.conn.WriteMessage(websocket.TextMessage, []byte(packet))
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
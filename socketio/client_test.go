@@ -0,0 +1,176 @@
+package socketio
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSocket(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespace  string
+		message    string
+		packetType byte
+		ackId      string
+		data       string
+		ok         bool
+	}{
+		{"event", "/", `42["saleFeed",{"a":1}]`, SOCKET_EVENT, "", `["saleFeed",{"a":1}]`, true},
+		{"short event name", "/", `42["x",1]`, SOCKET_EVENT, "", `["x",1]`, true},
+		{"long event name", "/", `42["aMuchLongerEventNameThanUsual",1]`, SOCKET_EVENT, "", `["aMuchLongerEventNameThanUsual",1]`, true},
+		{"ack id", "/", `4212["saleFeed"]`, SOCKET_EVENT, "12", `["saleFeed"]`, true},
+		{"connect", "/", `40{"sid":"abc"}`, SOCKET_CONNECT, "", `{"sid":"abc"}`, true},
+		{"connect without data", "/", `40`, SOCKET_CONNECT, "", "", true},
+		{"disconnect", "/", `41`, SOCKET_DISCONNECT, "", "", true},
+		{"namespace", "/feed", `42/feed,["saleFeed"]`, SOCKET_EVENT, "", `["saleFeed"]`, true},
+		{"namespace with ack id", "/feed", `42/feed,7["saleFeed"]`, SOCKET_EVENT, "7", `["saleFeed"]`, true},
+		{"namespace without data", "/feed", `40/feed`, SOCKET_CONNECT, "", "", true},
+		{"other namespace", "/", `42/feed,["saleFeed"]`, 0, "", "", false},
+		{"default namespace on custom client", "/feed", `42["saleFeed"]`, 0, "", "", false},
+		{"binary event", "/", `451-["saleFeed",{"_placeholder":true,"num":0}]`, 0, "", "", false},
+		{"binary ack", "/", `461-[{"_placeholder":true,"num":0}]`, 0, "", "", false},
+		{"engine ping", "/", `2`, 0, "", "", false},
+		{"engine message without packet", "/", `4`, 0, "", "", false},
+		{"empty", "/", ``, 0, "", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &Client{namespace: test.namespace}
+
+			packetType, ackId, data, ok := client.parseSocket([]byte(test.message))
+			if ok != test.ok || packetType != test.packetType || ackId != test.ackId || data != test.data {
+				t.Errorf("parseSocket(%q) = %q, %q, %q, %v, want %q, %q, %q, %v",
+					test.message, packetType, ackId, data, ok, test.packetType, test.ackId, test.data, test.ok)
+			}
+		})
+	}
+}
+
+func TestHandleSocket(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		args    []string
+		err     error
+	}{
+		{"event", `42["saleFeed",{"a":1}]`, []string{`{"a":1}`}, nil},
+		{"event without args", `42["saleFeed"]`, []string{}, nil},
+		{"event with ack id", `423["saleFeed",1,2]`, []string{"1", "2"}, nil},
+		{"other event", `42["other",1]`, nil, nil},
+		{"malformed event", `42["saleFeed"`, nil, nil},
+		{"disconnect", `41`, nil, ErrDisconnected},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &Client{namespace: "/", handlers: make(map[string][]Handler)}
+
+			var received []string
+			client.On("saleFeed", func(args []json.RawMessage) {
+				received = []string{}
+				for _, arg := range args {
+					received = append(received, string(arg))
+				}
+			})
+
+			if err := client.handleSocket([]byte(test.message)); err != test.err {
+				t.Fatalf("handleSocket(%q) error = %v, want %v", test.message, err, test.err)
+			}
+			if strings.Join(received, "|") != strings.Join(test.args, "|") || (received == nil) != (test.args == nil) {
+				t.Errorf("handleSocket(%q) dispatched %q, want %q", test.message, received, test.args)
+			}
+		})
+	}
+}
+
+func TestDialHandshake(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		// Packets the server sends after the open packet, once the client has asked to connect
+		replies []string
+		connect string
+		pongs   int
+		wantErr bool
+	}{
+		{"default namespace", "/", []string{`40{"sid":"abc"}`}, `40`, 0, false},
+		{"custom namespace", "/feed", []string{`40/feed,{"sid":"abc"}`}, `40/feed,`, 0, false},
+		{"ping before connect", "/", []string{`2`, `40{"sid":"abc"}`}, `40`, 1, false},
+		{"other namespace before connect", "/feed", []string{`40{"sid":"abc"}`, `40/feed,{"sid":"abc"}`}, `40/feed,`, 0, false},
+		{"connect error", "/", []string{`44{"message":"Not authorized"}`}, `40`, 0, true},
+	}
+
+	upgrader := websocket.Upgrader{}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			received := make(chan []string, 1)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+
+				conn.WriteMessage(websocket.TextMessage, []byte(`0{"sid":"engine","pingInterval":25000,"pingTimeout":20000}`))
+
+				var packets []string
+				_, message, err := conn.ReadMessage()
+				if err == nil {
+					packets = append(packets, string(message))
+				}
+
+				for _, reply := range test.replies {
+					conn.WriteMessage(websocket.TextMessage, []byte(reply))
+					if reply == string(ENGINE_PING) {
+						if _, message, err = conn.ReadMessage(); err == nil {
+							packets = append(packets, string(message))
+						}
+					}
+				}
+
+				received <- packets
+				conn.ReadMessage()
+			}))
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			client, err := Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil, test.namespace)
+			if test.wantErr {
+				if err == nil {
+					client.Close()
+					t.Fatal("Dial succeeded, want a connect error")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Dial failed: %v", err)
+				}
+				defer client.Close()
+
+				if timeout := client.PingTimeout(); timeout != 45*time.Second {
+					t.Errorf("PingTimeout() = %v, want 45s", timeout)
+				}
+			}
+
+			packets := <-received
+			if len(packets) != 1+test.pongs || packets[0] != test.connect {
+				t.Fatalf("server received %q, want connect %q and %d pongs", packets, test.connect, test.pongs)
+			}
+			for _, packet := range packets[1:] {
+				if packet != string(ENGINE_PONG) {
+					t.Errorf("server received %q, want a pong", packet)
+				}
+			}
+		})
+	}
+}
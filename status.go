@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+type StatusEntry struct {
+	Name  string
+	Value string
+}
+
+func formatBudget(budget Budget) string {
+	if !budget.Limited {
+		return "Unlimited"
+	}
+
+	return NewMoney(budget.Remaining, USD).String()
+}
+
+func GetStatus() []StatusEntry {
+	daily, weekly := risk.RemainingBudget()
+	p2p := GetP2PStats()
+
+	return []StatusEntry{
+		{Name: "Dmarket Balance", Value: NewMoney(balances.Available(), USD).String()},
+		{Name: "Reserved", Value: NewMoney(balances.Reserved(), USD).String()},
+		{Name: "Purchases Queued", Value: strconv.Itoa(dispatcher.QueueDepth())},
+		{Name: "Purchases In Flight", Value: strconv.Itoa(dispatcher.InFlight())},
+		{Name: "Dmarket Request Interval", Value: scheduler.Interval().String()},
+		{Name: "Skinport Feed", Value: skinportFeed.Status()},
+		{Name: "Skinport Active Listings", Value: strconv.Itoa(skinportListings.Active())},
+		{Name: "Skinport Cart", Value: skinportCart.Status()},
+		{Name: "Dmarket Clock Offset", Value: dmarketClient.Clock.Offset().Round(time.Millisecond).String()},
+		{Name: "P2P Pending", Value: strconv.Itoa(p2p[TRADE_PENDING])},
+		{Name: "P2P Delivered", Value: strconv.Itoa(p2p[TRADE_COMPLETED] + p2p[TRADE_SOLD])},
+		{Name: "P2P Undelivered", Value: strconv.Itoa(p2p[TRADE_CANCELLED] + p2p[TRADE_TIMED_OUT])},
+		{Name: "Daily Budget Left", Value: formatBudget(daily)},
+		{Name: "Weekly Budget Left", Value: formatBudget(weekly)},
+	}
+}
+
+func RunStatus(delayMinutes int) {
+	if delayMinutes <= 0 {
+		return
+	}
+
+	ticker := time.Tick(time.Duration(delayMinutes) * time.Minute)
+	for range ticker {
+		status := GetStatus()
+		for _, entry := range status {
+			InfoLogger.Printf("Status - %s: %s\n", entry.Name, entry.Value)
+		}
+
+		SendStatus(status)
+	}
+}
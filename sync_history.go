@@ -0,0 +1,197 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const HISTORY_PAGE_LIMIT = 100
+
+func getHistoryAmount(entry *dmarket.HistoryEntry) Money {
+	for _, change := range entry.Changes {
+		amount, err := strconv.ParseFloat(change.Money.Amount, 64)
+		if err == nil {
+			return NewMoney(amount, Currency(change.Money.Currency))
+		}
+	}
+
+	return Money{}
+}
+
+// SyncDmarketHistory imports closed buy orders and trade history into the local trade history,
+// marking trades the bot didn't make as manual. The history is saved once, after the import.
+func SyncDmarketHistory() error {
+	return history.Batch(syncDmarketHistory)
+}
+
+func syncDmarketHistory() error {
+	imported, matched, sold := 0, 0, 0
+	count := func(wasImported bool, wasMatched bool) {
+		if wasImported {
+			imported++
+		} else if wasMatched {
+			matched++
+		}
+	}
+
+	// Fills of the bot's targets while it was down are still the bot's
+	if _, err := targetStore.Load(); err != nil {
+		return err
+	}
+
+	cursor := ""
+	for {
+		closedObj, err := FetchClosedDmarketTargets(cursor)
+		if err != nil {
+			return err
+		}
+
+		for _, trade := range closedObj.Trades {
+			if !trade.IsFilled() {
+				continue
+			}
+
+			record := TradeRecord{
+				ID:     trade.TargetID + ":" + trade.OfferID,
+				Market: MARKET_DMARKET,
+				Type:   "target",
+				Title:  trade.Title,
+				Price:  NewMoney(trade.Price.Amount, Currency(trade.Price.Currency)),
+				Status: TRADE_COMPLETED,
+				Source: SOURCE_MANUAL,
+			}
+
+			if targetStore.Owns(trade.TargetID) {
+				record.Source = SOURCE_BOT
+			}
+
+			if closedAt, err := strconv.ParseInt(trade.ClosedAt, 10, 64); err == nil {
+				record.CreatedAt = time.Unix(closedAt, 0)
+			}
+
+			count(importRecord(record))
+		}
+
+		if closedObj.Cursor == "" || len(closedObj.Trades) == 0 {
+			break
+		}
+		cursor = closedObj.Cursor
+	}
+
+	var sales []dmarket.HistoryEntry
+	for offset := 0; ; offset += HISTORY_PAGE_LIMIT {
+		historyObj, err := FetchDmarketHistory("purchase,sell", offset, HISTORY_PAGE_LIMIT, PRIORITY_ACCOUNT)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range historyObj.Objects {
+			if !isSuccessfulHistoryEntry(&entry) {
+				continue
+			}
+
+			if entry.Type == "sell" {
+				sales = append(sales, entry)
+				continue
+			}
+
+			record := TradeRecord{
+				ID:        entry.OfferID,
+				Market:    MARKET_DMARKET,
+				Type:      "purchase",
+				Title:     entry.Subject,
+				Price:     getHistoryAmount(&entry),
+				Status:    TRADE_COMPLETED,
+				Source:    SOURCE_MANUAL,
+				OrderID:   entry.OrderID,
+				HistoryID: entry.ID,
+				CreatedAt: time.Unix(entry.CreatedAt, 0),
+			}
+
+			count(importRecord(record))
+		}
+
+		if len(historyObj.Objects) < HISTORY_PAGE_LIMIT {
+			break
+		}
+	}
+
+	// Sales are applied oldest first, each to the oldest held purchase of the same item
+	sort.Slice(sales, func(i, j int) bool {
+		return sales[i].CreatedAt < sales[j].CreatedAt
+	})
+	for _, entry := range sales {
+		if importSale(entry) {
+			sold++
+		}
+	}
+
+	message := fmt.Sprintf("Dmarket history synced: %d trades imported, %d matched to bot trades, %d sales recorded", imported, matched, sold)
+	InfoLogger.Println(message)
+	fmt.Println(message)
+
+	return nil
+}
+
+func isSuccessfulHistoryEntry(entry *dmarket.HistoryEntry) bool {
+	status := strings.ToLower(entry.Status)
+	return status == "success" || status == "successful" || status == "completed"
+}
+
+// importRecord adds a trade missing from the local history, returning whether it was imported
+// or matched to one the bot already recorded. Entries without an ID can do neither.
+func importRecord(record TradeRecord) (bool, bool) {
+	if record.ID == "" {
+		return false, false
+	}
+
+	if _, ok := history.Find(record.Market, record.ID); ok {
+		history.Update(record.Market, record.ID, func(existing *TradeRecord) {
+			if existing.Source == "" {
+				existing.Source = SOURCE_BOT
+			}
+			if existing.Status == TRADE_UNKNOWN || existing.Status == TRADE_PENDING {
+				existing.Status = record.Status
+			}
+			if existing.OrderID == "" {
+				existing.OrderID = record.OrderID
+			}
+			existing.HistoryID = record.HistoryID
+		})
+
+		return false, true
+	}
+
+	history.Add(record)
+	return true, false
+}
+
+func importSale(entry dmarket.HistoryEntry) bool {
+	if len(history.Filter(func(record *TradeRecord) bool {
+		return record.SaleHistoryID == entry.ID
+	})) > 0 {
+		return false
+	}
+
+	held := history.Filter(func(record *TradeRecord) bool {
+		return record.Market == MARKET_DMARKET && record.Title == entry.Subject && record.Status == TRADE_COMPLETED
+	})
+	if len(held) == 0 {
+		WarningLogger.Println("No held purchase found for sale of " + entry.Subject)
+		return false
+	}
+
+	sort.Slice(held, func(i, j int) bool {
+		return held[i].CreatedAt.Before(held[j].CreatedAt)
+	})
+
+	return history.Update(MARKET_DMARKET, held[0].ID, func(record *TradeRecord) {
+		record.Status = TRADE_SOLD
+		record.SalePrice = getHistoryAmount(&entry)
+		record.SaleHistoryID = entry.ID
+	})
+}
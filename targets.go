@@ -0,0 +1,379 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const TARGETS_FILE = "targets.json"
+
+// TargetRule configures a standing Dmarket buy target for an item
+type TargetRule struct {
+	Title  string `json:"title"`
+	Amount int    `json:"amount"`
+}
+
+type StoredTarget struct {
+	Title string `json:"title"`
+	// Filled or cancelled targets are kept, so their fills can still be told apart from manual ones
+	Closed bool `json:"closed,omitempty"`
+}
+
+// TargetStore remembers which Dmarket targets the bot created, so targets placed by hand are left alone
+type TargetStore struct {
+	mutex   sync.Mutex
+	path    string
+	targets map[string]StoredTarget
+}
+
+var targetStore = NewTargetStore(TARGETS_FILE)
+
+// Fills from before the bot started are recorded without a notification, they were reported by an earlier run or happened while it was down
+var targetFillsSince = time.Now()
+
+func NewTargetStore(path string) *TargetStore {
+	return &TargetStore{path: path, targets: make(map[string]StoredTarget)}
+}
+
+// Load reads the stored targets, returning false if there was no file yet
+func (s *TargetStore) Load() (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if err = json.Unmarshal(file, &s.targets); err == nil {
+		return true, nil
+	}
+
+	// Older versions only stored the title of each open target
+	var titles map[string]string
+	if json.Unmarshal(file, &titles) != nil {
+		return true, err
+	}
+
+	s.targets = make(map[string]StoredTarget)
+	for targetId, title := range titles {
+		s.targets[targetId] = StoredTarget{Title: title}
+	}
+
+	return true, nil
+}
+
+// save must be called with the mutex held
+func (s *TargetStore) save() {
+	file, err := json.MarshalIndent(s.targets, "", "  ")
+	if err != nil {
+		ErrorLogger.Println("Failed to encode targets: " + err.Error())
+		return
+	}
+
+	if err = ioutil.WriteFile(s.path, file, 0666); err != nil {
+		ErrorLogger.Println("Failed to save targets: " + err.Error())
+	}
+}
+
+func (s *TargetStore) Add(targetId string, title string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.targets[targetId] = StoredTarget{Title: title}
+	s.save()
+}
+
+// Close marks a target as no longer open, keeping it to attribute its fills
+func (s *TargetStore) Close(targetId string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	target, ok := s.targets[targetId]
+	if !ok || target.Closed {
+		return
+	}
+
+	target.Closed = true
+	s.targets[targetId] = target
+	s.save()
+}
+
+// Owns reports whether the bot created a target, open or closed
+func (s *TargetStore) Owns(targetId string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.targets[targetId]
+	return ok
+}
+
+func (s *TargetStore) OpenIDs() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var ids []string
+	for targetId, target := range s.targets {
+		if !target.Closed {
+			ids = append(ids, targetId)
+		}
+	}
+
+	return ids
+}
+
+// targetReservation is the balance reservation ID of an open target
+func targetReservation(targetId string) string {
+	return "target:" + targetId
+}
+
+// GetTargetPrice is the highest price we can bid for an item while keeping the required margin on Buff after target fees
+func GetTargetPrice(title string) Money {
+	buffPrice := GetBuffPrice(title, "")
+	price := buffPrice.Amount * (1 - RequiredProfitPercentage(0)/100) * (1 - config.TargetFeePercentage/100)
+
+	return NewMoney(math.Floor(price*100)/100, buffPrice.Currency)
+}
+
+func FetchDmarketTargets() ([]dmarket.Target, error) {
+	targetsObj, err := dmarketClient.Targets(PriorityContext(PRIORITY_ACCOUNT), "TargetStatusActive", "")
+	return targetsObj.Items, err
+}
+
+// FetchClosedDmarketTargets returns one page of closed targets, newest first
+func FetchClosedDmarketTargets(cursor string) (dmarket.ClosedTargetsResponse, error) {
+	return dmarketClient.ClosedTargets(PriorityContext(PRIORITY_ACCOUNT), cursor)
+}
+
+// CreateDmarketTarget places a target, returning its ID
+func CreateDmarketTarget(title string, amount int, price Money) (string, error) {
+	resultObj, err := dmarketClient.CreateTargets(PriorityContext(PRIORITY_ACCOUNT), dmarket.NewTarget{
+		Amount: amount,
+		Price:  dmarket.Price{Currency: string(price.Currency), Amount: price.Amount},
+		Title:  title,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err = resultObj.Err(); err != nil {
+		return "", errors.New("Failed to create target for " + title + ": " + err.Error())
+	}
+
+	if len(resultObj.Result) == 0 {
+		return "", errors.New("No target created for " + title)
+	}
+
+	return resultObj.Result[0].TargetID, nil
+}
+
+func DeleteDmarketTarget(targetId string) error {
+	_, err := dmarketClient.DeleteTargets(PriorityContext(PRIORITY_ACCOUNT), targetId)
+	return err
+}
+
+func RunTargets(delayMinutes int) {
+	if delayMinutes <= 0 || len(config.Targets) == 0 {
+		return
+	}
+
+	existed, err := targetStore.Load()
+	if err != nil {
+		ErrorLogger.Println("Failed to load targets: " + err.Error())
+	}
+
+	// Targets created before they were stored are adopted once by title
+	if !existed {
+		AdoptDmarketTargets()
+	}
+
+	UpdateTargets()
+
+	ticker := time.Tick(time.Duration(delayMinutes) * time.Minute)
+	for range ticker {
+		UpdateTargets()
+	}
+}
+
+func AdoptDmarketTargets() {
+	targets, err := FetchDmarketTargets()
+	if err != nil {
+		ErrorLogger.Println("Failed to fetch targets: " + err.Error())
+		return
+	}
+
+	titles := make(map[string]bool)
+	for _, rule := range config.Targets {
+		titles[rule.Title] = true
+	}
+
+	for _, target := range targets {
+		if titles[target.Title] {
+			targetStore.Add(target.TargetID, target.Title)
+		}
+	}
+}
+
+// cancelTarget deletes one of our targets and frees the balance it reserved
+func cancelTarget(target dmarket.Target) error {
+	if err := DeleteDmarketTarget(target.TargetID); err != nil {
+		return err
+	}
+
+	targetStore.Close(target.TargetID)
+	balances.Release(targetReservation(target.TargetID))
+	return nil
+}
+
+// UpdateTargets records fills, then creates, reprices or cancels our targets so they follow the Buff reference.
+// Open targets reserve their full cost, as Dmarket only charges the balance once they fill.
+func UpdateTargets() {
+	if RecordTargetFills() > 0 {
+		UpdateAvailableBalance()
+	}
+
+	targets, err := FetchDmarketTargets()
+	if err != nil {
+		ErrorLogger.Println("Failed to fetch targets: " + err.Error())
+		return
+	}
+
+	// Targets are keyed by ID, several may share a title and ones placed by hand aren't ours to manage
+	active := make(map[string]bool)
+	existing := make(map[string][]dmarket.Target)
+	for _, target := range targets {
+		if !targetStore.Owns(target.TargetID) {
+			continue
+		}
+
+		active[target.TargetID] = true
+		existing[target.Title] = append(existing[target.Title], target)
+		balances.Set(targetReservation(target.TargetID), target.Price.Amount*float64(target.Amount))
+	}
+
+	// Filled or cancelled elsewhere
+	for _, targetId := range targetStore.OpenIDs() {
+		if !active[targetId] {
+			targetStore.Close(targetId)
+			balances.Release(targetReservation(targetId))
+		}
+	}
+
+	for _, rule := range config.Targets {
+		price := GetTargetPrice(rule.Title)
+		ours := existing[rule.Title]
+
+		// Duplicates, e.g. from an interrupted update, are cancelled so only one target is kept per rule
+		for i := 1; i < len(ours); i++ {
+			InfoLogger.Println("Cancelling duplicate target " + ours[i].TargetID + " for " + rule.Title)
+			if err = cancelTarget(ours[i]); err != nil {
+				ErrorLogger.Println("Failed to cancel target: " + err.Error())
+			}
+		}
+
+		if len(ours) > 0 {
+			target := ours[0]
+
+			// Targets whose item no longer has a reference price are cancelled too
+			if price.Amount > 0 && math.Abs(target.Price.Amount-price.Amount)/price.Amount*100 < config.TargetRepriceThreshold {
+				continue
+			}
+
+			InfoLogger.Printf("Buff reference for %s moved, cancelling target at $%.2f\n", rule.Title, target.Price.Amount)
+			if err = cancelTarget(target); err != nil {
+				ErrorLogger.Println("Failed to cancel target: " + err.Error())
+				continue
+			}
+		}
+
+		if price.Amount <= 0 {
+			continue
+		}
+
+		amount := rule.Amount
+		if amount <= 0 {
+			amount = 1
+		}
+
+		total := NewMoney(price.Amount*float64(amount), price.Currency)
+		if total.Amount > balances.Free() {
+			InfoLogger.Println("Insufficient free balance for target on " + rule.Title)
+			continue
+		}
+
+		if err = risk.Check(TradeRecord{Title: rule.Title, Price: total}, GetBankroll()); err != nil {
+			InfoLogger.Println(err)
+			continue
+		}
+
+		InfoLogger.Printf("Creating target for %s at %s\n", rule.Title, price)
+		targetId, err := CreateDmarketTarget(rule.Title, amount, price)
+		if err != nil {
+			ReportError(err)
+			continue
+		}
+
+		targetStore.Add(targetId, rule.Title)
+		balances.Set(targetReservation(targetId), total.Amount)
+	}
+}
+
+// RecordTargetFills adds fills of our targets to the trade history, returning how many were new.
+// Targets placed by hand are left to sync-history, which imports them as manual trades.
+func RecordTargetFills() int {
+	closedObj, err := FetchClosedDmarketTargets("")
+	if err != nil {
+		ErrorLogger.Println("Failed to fetch closed targets: " + err.Error())
+		return 0
+	}
+
+	fills := 0
+	for _, trade := range closedObj.Trades {
+		if !trade.IsFilled() {
+			if trade.Status != dmarket.CLOSED_TARGET_REVERTED {
+				WarningLogger.Println("Unknown closed target status " + trade.Status + " for " + trade.Title)
+			}
+			continue
+		}
+
+		if !targetStore.Owns(trade.TargetID) {
+			continue
+		}
+
+		id := trade.TargetID + ":" + trade.OfferID
+		if _, ok := history.Find(MARKET_DMARKET, id); ok {
+			continue
+		}
+
+		record := TradeRecord{
+			ID:     id,
+			Market: MARKET_DMARKET,
+			Type:   "target",
+			Title:  trade.Title,
+			Price:  NewMoney(trade.Price.Amount, Currency(trade.Price.Currency)),
+			Status: TRADE_COMPLETED,
+			Source: SOURCE_BOT,
+		}
+
+		if closedAt, err := strconv.ParseInt(trade.ClosedAt, 10, 64); err == nil {
+			record.CreatedAt = time.Unix(closedAt, 0)
+		}
+
+		history.Add(record)
+		if !record.CreatedAt.Before(targetFillsSince) {
+			SendTargetFill(record)
+		}
+		fills++
+	}
+
+	return fills
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"csgoTrader/dmarket"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serveClosedTargets serves a single page of closed targets
+func serveClosedTargets(t *testing.T, trades []dmarket.ClosedTarget) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(dmarket.ClosedTargetsResponse{Trades: trades})
+	}))
+	t.Cleanup(server.Close)
+
+	client := dmarketClient
+	dmarketClient = dmarket.NewClient("", "", server.URL, nil)
+	t.Cleanup(func() { dmarketClient = client })
+}
+
+func TestRecordTargetFills(t *testing.T) {
+	started := time.Now().Add(-time.Hour)
+	closedAt := func(offset time.Duration) string {
+		return strconv.FormatInt(started.Add(offset).Unix(), 10)
+	}
+
+	tests := []struct {
+		name   string
+		trade  dmarket.ClosedTarget
+		owned  bool
+		fills  int
+		embeds int
+	}{
+		{"our fill", dmarket.ClosedTarget{TargetID: "t1", OfferID: "o1", Status: dmarket.CLOSED_TARGET_SUCCESSFUL, ClosedAt: closedAt(time.Minute)}, true, 1, 1},
+		{"trade protected fill", dmarket.ClosedTarget{TargetID: "t1", OfferID: "o1", Status: dmarket.CLOSED_TARGET_TRADE_PROTECTED, ClosedAt: closedAt(time.Minute)}, true, 1, 1},
+		{"fill from before the start", dmarket.ClosedTarget{TargetID: "t1", OfferID: "o1", Status: dmarket.CLOSED_TARGET_SUCCESSFUL, ClosedAt: closedAt(-time.Minute)}, true, 1, 0},
+		{"manual target", dmarket.ClosedTarget{TargetID: "t2", OfferID: "o1", Status: dmarket.CLOSED_TARGET_SUCCESSFUL, ClosedAt: closedAt(time.Minute)}, false, 0, 0},
+		{"reverted", dmarket.ClosedTarget{TargetID: "t1", OfferID: "o1", Status: dmarket.CLOSED_TARGET_REVERTED, ClosedAt: closedAt(time.Minute)}, true, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useTempHistory(t)
+			webhook := useFakeWebhook(t)
+			serveClosedTargets(t, []dmarket.ClosedTarget{test.trade})
+
+			previousStore, previousSince := targetStore, targetFillsSince
+			targetStore = NewTargetStore(filepath.Join(t.TempDir(), TARGETS_FILE))
+			targetFillsSince = started
+			t.Cleanup(func() { targetStore, targetFillsSince = previousStore, previousSince })
+
+			if test.owned {
+				targetStore.Add(test.trade.TargetID, test.trade.Title)
+			}
+
+			if fills := RecordTargetFills(); fills != test.fills {
+				t.Errorf("RecordTargetFills() = %d, want %d", fills, test.fills)
+			}
+			if webhook.embeds != test.embeds {
+				t.Errorf("RecordTargetFills() sent %d notifications, want %d", webhook.embeds, test.embeds)
+			}
+
+			records := history.Filter(func(record *TradeRecord) bool { return true })
+			if len(records) != test.fills {
+				t.Fatalf("history has %d records, want %d", len(records), test.fills)
+			}
+			for _, record := range records {
+				if record.Source != SOURCE_BOT || record.Status != TRADE_COMPLETED {
+					t.Errorf("fill recorded as %s %s, want %s %s", record.Source, record.Status, SOURCE_BOT, TRADE_COMPLETED)
+				}
+			}
+
+			// Fills already in the history aren't recorded twice
+			if fills := RecordTargetFills(); fills != 0 {
+				t.Errorf("second RecordTargetFills() = %d, want 0", fills)
+			}
+		})
+	}
+}
+
+func TestTargetStore(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		open []string
+	}{
+		{"current format", `{"t1": {"title": "AK-47"}, "t2": {"title": "AWP", "closed": true}}`, []string{"t1"}},
+		{"old format", `{"t1": "AK-47", "t2": "AWP"}`, []string{"t1", "t2"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), TARGETS_FILE)
+			if err := ioutil.WriteFile(path, []byte(test.file), 0666); err != nil {
+				t.Fatal(err)
+			}
+
+			store := NewTargetStore(path)
+			if existed, err := store.Load(); !existed || err != nil {
+				t.Fatalf("Load() = %v, %v", existed, err)
+			}
+			if open := store.OpenIDs(); len(open) != len(test.open) {
+				t.Errorf("OpenIDs() = %v, want %v", open, test.open)
+			}
+
+			// Closed targets are still ours, also after a reload
+			store.Close("t1")
+			reloaded := NewTargetStore(path)
+			reloaded.Load()
+			for _, targetId := range []string{"t1", "t2"} {
+				if !reloaded.Owns(targetId) {
+					t.Errorf("Owns(%q) = false after closing and reloading", targetId)
+				}
+			}
+			if reloaded.Owns("t3") {
+				t.Error("Owns(\"t3\") = true for a target we never stored")
+			}
+			for _, targetId := range reloaded.OpenIDs() {
+				if targetId == "t1" {
+					t.Error("closed target t1 is still open")
+				}
+			}
+		})
+	}
+}
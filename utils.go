@@ -1,62 +1,15 @@
 package main
 
 import (
-	"crypto/ed25519"
-	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"io/ioutil"
 	"net/http"
-	"strconv"
-	"strings"
-	"time"
 )
 
-const DMARKET_API_URL = "https://api.dmarket.com"
 const PRICES_URL = "https://prices.csgotrader.app/latest/prices_v6.json"
 
 var marketPrices = make(map[string]MarketPrices)
 
-func GetPrivateKey(s string) *[64]byte {
-	b, _ := hex.DecodeString(s)
-	var privateKey [64]byte
-	copy(privateKey[:], b[:64])
-
-	return &privateKey
-}
-
-func Sign(pk, msg string) string {
-	b := GetPrivateKey(pk)
-	return hex.EncodeToString(ed25519.Sign((*b)[:], []byte(msg)))
-}
-
-func SendSignedDmarketRequest(method string, path string, body string) (*http.Response, error) {
-	timestamp := strconv.Itoa(int(time.Now().UTC().Unix()))
-	unsigned := method + path + body + timestamp
-	signature := Sign(config.DmarketPrivateKey, unsigned)
-
-	req, _ := http.NewRequest(method, DMARKET_API_URL+path, ioutil.NopCloser(strings.NewReader(body)))
-	req.Header.Set("X-Sign-Date", timestamp)
-	req.Header.Set("X-Request-Sign", "dmar ed25519 "+signature)
-	req.Header.Set("X-Api-Key", config.DmarketPublicKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
-	return http.DefaultClient.Do(req)
-}
-
-func HandleError(response *http.Response) {
-	ErrorLogger.Println("Erroneous response received: " + strconv.Itoa(response.StatusCode))
-
-	body, _ := ioutil.ReadAll(response.Body)
-	response.Body.Close()
-
-	var errorObj DmarketError
-	json.Unmarshal(body, &errorObj)
-
-	ReportError(errors.New(errorObj.Message))
-}
-
 func fetchPrices() {
 	response, _ := http.DefaultClient.Get(PRICES_URL)
 
@@ -74,16 +27,6 @@ func PercentageDifference(cost float64, revenue float64) float64 {
 	return ((revenue - cost) / revenue) * 100
 }
 
-type DmarketError struct {
-	Error   string `json:"error"`
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Details []struct {
-		TypeURL string `json:"type_url"`
-		Value   string `json:"value"`
-	} `json:"details"`
-}
-
 type MarketPrices struct {
 	Steam struct {
 		Last24H float64 `json:"last_24h"`